@@ -0,0 +1,158 @@
+package metaextractor
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// appLinksPrefix starts the App Links meta properties (e.g. "al:ios:url")
+const appLinksPrefix = "al:"
+
+// AppLinks are the App Links (al:*) and Facebook (fb:*) meta tags pointing
+// to the native apps that can open the document
+type AppLinks struct {
+	Android          *AppLink     `json:"android,omitempty"`
+	FacebookAppID    string       `json:"fb_app_id,omitempty"`
+	FacebookPages    []string     `json:"fb_pages,omitempty"` // Page IDs, from comma-separated or repeated tags
+	IOS              *AppLink     `json:"ios,omitempty"`
+	IPad             *AppLink     `json:"ipad,omitempty"`
+	IPhone           *AppLink     `json:"iphone,omitempty"`
+	Web              *AppLinksWeb `json:"web,omitempty"`
+	Windows          *AppLink     `json:"windows,omitempty"`
+	WindowsPhone     *AppLink     `json:"windows_phone,omitempty"`
+	WindowsUniversal *AppLink     `json:"windows_universal,omitempty"`
+}
+
+// AppLink is the app of a platform, only the first one is kept when a page
+// lists several apps for the same platform
+type AppLink struct {
+	AppID      string `json:"app_id,omitempty"` // Windows
+	AppName    string `json:"app_name,omitempty"`
+	AppStoreID string `json:"app_store_id,omitempty"` // iOS, iPad and iPhone
+	Class      string `json:"class,omitempty"`        // Android activity
+	Package    string `json:"package,omitempty"`      // Android
+	URL        string `json:"url,omitempty"`          // Deep link opening the app
+}
+
+// AppLinksWeb is the web fallback of the App Links
+type AppLinksWeb struct {
+	ShouldFallback bool   `json:"should_fallback"` // True unless al:web:should_fallback is "false" or "0"
+	URL            string `json:"url,omitempty"`
+}
+
+// FieldAppLinks starts the field names of the App Links, which follow their
+// JSON paths in the Result (e.g. "app_links.ios.url" or "app_links.fb_app_id")
+const FieldAppLinks = "app_links"
+
+// appLinkFields returns the field of an app to set for each App Links property
+var appLinkFields = map[string]func(*AppLink) *string{
+	"app_id":       func(a *AppLink) *string { return &a.AppID },
+	"app_name":     func(a *AppLink) *string { return &a.AppName },
+	"app_store_id": func(a *AppLink) *string { return &a.AppStoreID },
+	"class":        func(a *AppLink) *string { return &a.Class },
+	"package":      func(a *AppLink) *string { return &a.Package },
+	"url":          func(a *AppLink) *string { return &a.URL },
+}
+
+// addMetaAppLink adds an al:* or fb:* meta tag to the App Links
+func (e *extractor) addMetaAppLink(t html.Token) {
+	key, content := "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName, TagProperty:
+			if k := strings.ToLower(attr.Val); strings.HasPrefix(k, appLinksPrefix) || k == TagFBAppID || k == TagFBPages {
+				key = k
+			}
+		case TagContent:
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	if len(key) == 0 || len(content) == 0 {
+		return
+	}
+
+	links := e.result.AppLinks
+	if links == nil {
+		links = &AppLinks{}
+	}
+	if !e.setAppLink(links, key, content) {
+		return
+	}
+	e.result.AppLinks = links
+}
+
+// setAppLink sets the field of the App Links matching a meta property, the
+// first value wins. It returns false if the property is not known.
+func (e *extractor) setAppLink(l *AppLinks, key, value string) bool {
+	switch key {
+	case TagFBAppID:
+		e.setFirst(&l.FacebookAppID, FieldAppLinks+".fb_app_id", key, value)
+		return true
+	case TagFBPages:
+		for _, raw := range strings.Split(value, ",") {
+			var page string
+			e.set(&page, FieldAppLinks+".fb_pages", key, strings.TrimSpace(raw))
+			if len(page) > 0 && !slices.Contains(l.FacebookPages, page) {
+				l.FacebookPages = append(l.FacebookPages, page)
+			}
+		}
+		return true
+	}
+
+	platform, property, ok := strings.Cut(strings.TrimPrefix(key, appLinksPrefix), ":")
+	if !ok {
+		return false
+	}
+	if platform == "web" {
+		return e.setAppLinksWeb(l, key, property, value)
+	}
+
+	var app **AppLink
+	switch platform {
+	case "android":
+		app = &l.Android
+	case "ios":
+		app = &l.IOS
+	case "ipad":
+		app = &l.IPad
+	case "iphone":
+		app = &l.IPhone
+	case "windows":
+		app = &l.Windows
+	case "windows_phone":
+		app = &l.WindowsPhone
+	case "windows_universal":
+		app = &l.WindowsUniversal
+	default:
+		return false
+	}
+	field, known := appLinkFields[property]
+	if !known {
+		return false
+	}
+	if *app == nil {
+		*app = &AppLink{}
+	}
+	// The platforms and properties are named like their JSON keys
+	e.setFirst(field(*app), FieldAppLinks+"."+platform+"."+property, key, value)
+	return true
+}
+
+// setAppLinksWeb sets a property of the web fallback
+func (e *extractor) setAppLinksWeb(l *AppLinks, key, property, value string) bool {
+	if property != "url" && property != "should_fallback" {
+		return false
+	}
+	if l.Web == nil {
+		l.Web = &AppLinksWeb{ShouldFallback: true}
+	}
+	switch property {
+	case "url":
+		e.setFirst(&l.Web.URL, FieldAppLinks+".web.url", key, value)
+	case "should_fallback":
+		l.Web.ShouldFallback = value != "0" && !strings.EqualFold(value, "false")
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractWithOptionsAppLinks tests collecting the App Links per platform
+func TestExtractWithOptionsAppLinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected *AppLinks
+	}{
+		{
+			name: "every platform",
+			mockHTML: `<head>
+				<meta property="al:ios:url" content="example://story/42">
+				<meta property="al:ios:app_store_id" content="12345">
+				<meta property="al:ios:app_name" content="Example">
+				<meta property="al:iphone:url" content="example-iphone://story/42">
+				<meta property="al:ipad:app_store_id" content="67890">
+				<meta property="al:android:url" content="example://story/42">
+				<meta property="al:android:package" content="com.example.app">
+				<meta property="al:android:class" content="com.example.app.StoryActivity">
+				<meta property="al:windows_phone:app_id" content="a1b2">
+				<meta property="al:windows:url" content="example-win://story/42">
+				<meta property="al:windows_universal:app_name" content="Example UWP">
+				<meta property="al:web:url" content="https://example.com/story/42">
+				<meta property="fb:app_id" content="1234567890">
+				<meta property="fb:pages" content="111, 222">
+			</head>`,
+			expected: &AppLinks{
+				Android: &AppLink{
+					Class:   "com.example.app.StoryActivity",
+					Package: "com.example.app",
+					URL:     "example://story/42",
+				},
+				FacebookAppID:    "1234567890",
+				FacebookPages:    []string{"111", "222"},
+				IOS:              &AppLink{AppName: "Example", AppStoreID: "12345", URL: "example://story/42"},
+				IPad:             &AppLink{AppStoreID: "67890"},
+				IPhone:           &AppLink{URL: "example-iphone://story/42"},
+				Web:              &AppLinksWeb{ShouldFallback: true, URL: "https://example.com/story/42"},
+				Windows:          &AppLink{URL: "example-win://story/42"},
+				WindowsPhone:     &AppLink{AppID: "a1b2"},
+				WindowsUniversal: &AppLink{AppName: "Example UWP"},
+			},
+		},
+		{
+			name: "first app of a platform wins",
+			mockHTML: `<head><meta property="al:android:package" content="com.example.app">
+				<meta property="al:android:package" content="com.example.lite">
+				<meta property="fb:pages" content="111"><meta property="fb:pages" content="222,111"></head>`,
+			expected: &AppLinks{
+				Android:       &AppLink{Package: "com.example.app"},
+				FacebookPages: []string{"111", "222"},
+			},
+		},
+		{
+			name:     "web fallback disabled",
+			mockHTML: `<head><meta property="al:web:should_fallback" content="false"></head>`,
+			expected: &AppLinks{Web: &AppLinksWeb{}},
+		},
+		{
+			name:     "web fallback enabled",
+			mockHTML: `<head><meta property="al:web:should_fallback" content="true"></head>`,
+			expected: &AppLinks{Web: &AppLinksWeb{ShouldFallback: true}},
+		},
+		{
+			name: "unknown and empty tags",
+			mockHTML: `<head><meta property="al:blackberry:url" content="bb://x">
+				<meta property="al:ios:colour" content="red">
+				<meta property="al:ios" content="x">
+				<meta property="al:ios:url" content=" ">
+				<meta property="fb:admins" content="1"></head>`,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{AppLinks: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.AppLinks)
+		})
+	}
+
+	t.Run("values are cleaned up and truncated like the tags", func(t *testing.T) {
+		page := `<head>
+			<meta property="al:ios:url" content="` + strings.Repeat("a", 20000) + `">
+			<meta property="al:android:package" content="  <b>com.example.app</b> ">
+			<meta property="fb:pages" content="111, <i>222</i>">
+			<meta property="al:web:url" content="https://example.com/story/42"></head>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{
+			AppLinks: true, Normalize: true, Provenance: true, StripTags: true, Warnings: true,
+		})
+		require.NotNil(t, result)
+		require.NotNil(t, result.AppLinks)
+		assert.Len(t, result.AppLinks.IOS.URL, MaxFieldLength)
+		assert.Equal(t, "com.example.app", result.AppLinks.Android.Package)
+		assert.Equal(t, []string{"111", "222"}, result.AppLinks.FacebookPages)
+
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningTruncated, result.Warnings[0].Code)
+		assert.Equal(t, "app_links.ios.url", result.Warnings[0].Field)
+		assert.Equal(t, Provenance{Line: 5, Offset: strings.Index(page, `<meta property="al:web:url"`), Source: "al:web:url"}, result.Provenance["app_links.web.url"])
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta property="al:ios:url" content="example://"></head>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.AppLinks)
+	})
+}
@@ -0,0 +1,475 @@
+package metaextractor
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Author sources that are not a meta tag
+const (
+	AuthorSourceByline   = "byline"     // Element with a byline or author class (e.g. <p class="byline">)
+	AuthorSourceItemprop = "itemprop"   // Element or meta tag with itemprop="author"
+	AuthorSourceJSONLD   = "json-ld"    // author or creator of the main JSON-LD node
+	AuthorSourceRel      = "rel=author" // <a rel="author"> or <link rel="author">
+)
+
+// Author limits
+const (
+	maxAuthorNameLength = 100
+	maxAuthorNameWords  = 6
+)
+
+// Author is a person credited for the document
+type Author struct {
+	Handle  string   `json:"handle,omitempty"` // Social handle (e.g. "@jane" from twitter:creator)
+	Name    string   `json:"name,omitempty"`   // Empty when only a profile URL or handle was found
+	Sources []string `json:"sources"`          // Tags and markup the author was found in, in the order found
+	URL     string   `json:"url,omitempty"`    // Profile page
+}
+
+// authorMetaTags are the meta names and properties holding authors, by their source
+var authorMetaTags = map[string]string{
+	TagArticleAuthor:  TagArticleAuthor,
+	TagMetaAuthor:     TagMetaAuthor,
+	TagOGAuthor:       TagOGAuthor,
+	TagTwitterCreator: TagTwitterCreator,
+}
+
+// bylinePrefixes are removed from the start of a byline (lowercase, longest first)
+var bylinePrefixes = []string{
+	"written by", "posted by", "story by", "words by", "report by", "by",
+}
+
+// bylineSeparators split a byline into names and the details around them,
+// commas are handled separately by splitCommas
+var bylineSeparators = []string{"|", "·", "•", ";", " and ", " & ", " with "}
+
+// bylineRoleWords mark the parts of a byline that are a role or a date rather than a name
+var bylineRoleWords = map[string]bool{
+	"columnist": true, "contributor": true, "correspondent": true, "editor": true, "minute": true,
+	"minutes": true, "min": true, "photographer": true, "published": true, "read": true,
+	"reporter": true, "staff": true, "updated": true, "writer": true,
+}
+
+// socialHosts are the hosts of profile URLs that hold a social handle
+var socialHosts = map[string]bool{
+	"twitter.com": true, "www.twitter.com": true, "x.com": true, "www.x.com": true,
+}
+
+// authorState holds the authors found in the tags and markup for the Authors option
+type authorState struct {
+	authors []Author
+	capture *authorCapture // Byline element being read
+	found   bool           // Found a byline in the markup, the body is not read further for authors
+}
+
+// authorCapture is the text and links of a byline element being read
+type authorCapture struct {
+	depth     int    // Depth of nested tag elements
+	href      string // href of the element itself, when it is a link
+	linkHref  string // href of the link being read inside the element
+	linkStart int    // Start of the text of the link being read
+	links     map[string]string
+	source    string
+	tag       string
+	text      []byte
+}
+
+// addMetaAuthor adds the authors of a meta tag (name, og:author, article:author,
+// twitter:creator or itemprop="author")
+func (e *extractor) addMetaAuthor(t html.Token) {
+	source, content := "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName, TagProperty:
+			if s, ok := authorMetaTags[strings.ToLower(attr.Val)]; ok {
+				source = s
+			}
+		case TagItemprop:
+			if strings.EqualFold(attr.Val, TagMetaAuthor) {
+				source = AuthorSourceItemprop
+			}
+		case TagContent:
+			content = attr.Val
+		}
+	}
+	if len(source) == 0 || len(strings.TrimSpace(content)) == 0 {
+		return
+	}
+
+	if source == TagTwitterCreator {
+		if handle := socialHandle(content); len(handle) > 0 {
+			e.author.authors = append(e.author.authors, Author{Handle: handle, Sources: []string{source}})
+		}
+		return
+	}
+	e.author.authors = append(e.author.authors, authorsFromValue(content, source)...)
+}
+
+// authorStartTag starts reading a byline element, or follows the links inside the one being read
+func (e *extractor) authorStartTag(t html.Token, tt html.TokenType) {
+	if c := e.author.capture; c != nil {
+		switch {
+		case t.Data == c.tag && tt == html.StartTagToken:
+			c.depth++
+		case t.Data == "a":
+			c.linkHref, c.linkStart = attrValue(t, TagHref), len(c.text)
+		case t.Data == "br":
+			c.text = append(c.text, ',')
+		}
+		return
+	}
+
+	source := authorMarkup(t)
+	if len(source) == 0 {
+		return
+	}
+	if t.Data == TagLink {
+		if href := strings.TrimSpace(attrValue(t, TagHref)); len(href) > 0 {
+			e.author.authors = append(e.author.authors, Author{URL: href, Sources: []string{source}})
+		}
+		return
+	}
+	if tt == html.SelfClosingTagToken || voidTags[t.Data] {
+		return
+	}
+	e.author.capture = &authorCapture{depth: 1, source: source, tag: t.Data}
+	if t.Data == "a" {
+		e.author.capture.href = strings.TrimSpace(attrValue(t, TagHref))
+	}
+}
+
+// authorEndTag finishes the byline element being read, or a link inside it
+func (e *extractor) authorEndTag(name string) {
+	c := e.author.capture
+	if c == nil {
+		return
+	}
+	if name == "a" && len(c.linkHref) > 0 {
+		if c.links == nil {
+			c.links = make(map[string]string)
+		}
+		if text := cleanAuthorName(string(c.text[c.linkStart:])); len(text) > 0 {
+			c.links[text] = strings.TrimSpace(c.linkHref)
+		}
+		c.linkHref = ""
+	}
+	if name != c.tag {
+		return
+	}
+	if c.depth--; c.depth > 0 {
+		return
+	}
+
+	e.author.capture = nil
+	names := splitByline(string(c.text))
+	for _, name := range names {
+		author := Author{Name: name, URL: c.links[name], Sources: []string{c.source}}
+		if len(names) == 1 && len(c.href) > 0 {
+			author.URL = c.href
+		}
+		e.author.authors = append(e.author.authors, author)
+	}
+	if len(names) > 0 {
+		e.author.found = true
+	}
+}
+
+// authorText adds text to the byline element being read
+func (e *extractor) authorText(text []byte) {
+	if c := e.author.capture; c != nil && len(c.text) < MaxFieldLength {
+		c.text = append(c.text, text...)
+	}
+}
+
+// authorMarkup returns the source of an element that holds an author, or an empty string
+func authorMarkup(t html.Token) string {
+	var class string
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagRel:
+			if (t.Data == "a" || t.Data == TagLink) && slices.Contains(strings.Fields(strings.ToLower(attr.Val)), TagMetaAuthor) {
+				return AuthorSourceRel
+			}
+		case TagItemprop:
+			if t.Data != TagMeta && slices.Contains(strings.Fields(strings.ToLower(attr.Val)), TagMetaAuthor) {
+				return AuthorSourceItemprop
+			}
+		case "class":
+			class = strings.ToLower(attr.Val)
+		}
+	}
+	if t.Data == TagLink || t.Data == TagMeta {
+		return ""
+	}
+	for _, word := range strings.Fields(class) {
+		if strings.Contains(word, "comment") {
+			return ""
+		}
+		if strings.Contains(word, "byline") || word == TagMetaAuthor || word == "author-name" ||
+			strings.HasSuffix(word, "-author") || strings.HasSuffix(word, "__author") {
+			return AuthorSourceByline
+		}
+	}
+	return ""
+}
+
+// jsonLDAuthors returns the authors and creators of the main JSON-LD nodes,
+// following references to the Person nodes of a @graph
+func jsonLDAuthors(nodes []map[string]any) []Author {
+	ids := make(map[string]map[string]any)
+	for _, node := range nodes {
+		if id, ok := node["@id"].(string); ok {
+			ids[id] = node
+		}
+	}
+
+	var authors []Author
+	var add func(v any)
+	add = func(v any) {
+		switch v := v.(type) {
+		case string:
+			authors = append(authors, authorsFromValue(v, AuthorSourceJSONLD)...)
+		case []any:
+			for _, item := range v {
+				add(item)
+			}
+		case map[string]any:
+			if id, ok := v["@id"].(string); ok && len(v) == 1 {
+				if node, found := ids[id]; found {
+					v = node
+				}
+			}
+			author := Author{
+				Name:    cleanAuthorName(jsonLDString(v["name"])),
+				Sources: []string{AuthorSourceJSONLD},
+				URL:     strings.TrimSpace(jsonLDString(v["url"])),
+			}
+			for _, sameAs := range jsonLDStrings(v["sameAs"]) {
+				if author.Handle = socialHandle(sameAs); len(author.Handle) > 0 {
+					break
+				}
+			}
+			if len(author.Name) > 0 || len(author.URL) > 0 {
+				authors = append(authors, author)
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		if jsonLDIsMain(node) {
+			add(node["author"])
+			add(node["creator"])
+		}
+	}
+	return authors
+}
+
+// jsonLDStrings returns a property that may be a single string or a list of strings
+func jsonLDStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// pickAuthors merges the JSON-LD authors, which come first, with the ones
+// found in the tags and markup
+func (e *extractor) pickAuthors() {
+	authors := append(jsonLDAuthors(jsonLDNodes(e.jsonLD)), e.author.authors...)
+	e.result.Authors = mergeAuthors(authors)
+}
+
+// mergeAuthors de-duplicates authors with the same name, URL or handle, and
+// gives the URL and handle found on their own to the author when there is only one
+func mergeAuthors(authors []Author) []Author {
+	if len(authors) == 0 {
+		return nil
+	}
+	merged := make([]Author, 0, len(authors))
+	for _, author := range authors {
+		if i := slices.IndexFunc(merged, func(m Author) bool { return isSameAuthor(m, author) }); i >= 0 {
+			mergeAuthor(&merged[i], author)
+			continue
+		}
+		merged = append(merged, author)
+	}
+
+	named := slices.IndexFunc(merged, func(a Author) bool { return len(a.Name) > 0 })
+	if named < 0 || slices.ContainsFunc(merged[named+1:], func(a Author) bool { return len(a.Name) > 0 }) {
+		return merged
+	}
+	single := merged[named]
+	var unnamed []Author
+	for i, author := range merged {
+		if i == named {
+			continue
+		}
+		if (len(author.URL) > 0 && len(single.URL) > 0 && author.URL != single.URL) ||
+			(len(author.Handle) > 0 && len(single.Handle) > 0 && !strings.EqualFold(author.Handle, single.Handle)) {
+			unnamed = append(unnamed, author)
+			continue
+		}
+		mergeAuthor(&single, author)
+	}
+	return append([]Author{single}, unnamed...)
+}
+
+// isSameAuthor returns true if a and b share a name, URL or handle
+func isSameAuthor(a, b Author) bool {
+	return (len(a.Name) > 0 && comparableName(a.Name) == comparableName(b.Name)) ||
+		(len(a.URL) > 0 && a.URL == b.URL) ||
+		(len(a.Handle) > 0 && strings.EqualFold(a.Handle, b.Handle))
+}
+
+// mergeAuthor fills the missing details of dst from src and adds its sources
+func mergeAuthor(dst *Author, src Author) {
+	if len(dst.Name) == 0 {
+		dst.Name = src.Name
+	}
+	if len(dst.URL) == 0 {
+		dst.URL = src.URL
+	}
+	if len(dst.Handle) == 0 {
+		dst.Handle = src.Handle
+	}
+	for _, source := range src.Sources {
+		if !slices.Contains(dst.Sources, source) {
+			dst.Sources = append(dst.Sources, source)
+		}
+	}
+}
+
+// authorsFromValue returns the authors of a text value, which may be a
+// profile URL, a social handle or a byline naming several people
+func authorsFromValue(value, source string) []Author {
+	value = strings.TrimSpace(value)
+	if isAbsoluteURL(value) {
+		return []Author{{Handle: socialHandle(value), Sources: []string{source}, URL: value}}
+	}
+	if handle := socialHandle(value); len(handle) > 0 {
+		return []Author{{Handle: handle, Sources: []string{source}}}
+	}
+	var authors []Author
+	for _, name := range splitByline(value) {
+		authors = append(authors, Author{Name: name, Sources: []string{source}})
+	}
+	return authors
+}
+
+// splitByline returns the names in a byline such as "By Jane Doe and John
+// Smith, Staff Writers | May 12, 2024", leaving out roles and dates
+func splitByline(byline string) []string {
+	parts := []string{NormalizeText(byline)}
+	for _, separator := range bylineSeparators {
+		var split []string
+		for _, part := range parts {
+			split = append(split, strings.Split(part, separator)...)
+		}
+		parts = split
+	}
+
+	var names []string
+	for _, part := range parts {
+		for _, segment := range splitCommas(part) {
+			if name := cleanAuthorName(segment); len(name) > 0 && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// splitCommas splits a part of a byline on its commas when every name in it
+// has several words, keeping "Doe, Jane" and "Jane Doe, Jr." as a single name
+func splitCommas(part string) []string {
+	segments := strings.Split(part, ",")
+	for _, segment := range segments {
+		if name := cleanAuthorName(segment); len(strings.Fields(name)) == 1 {
+			return []string{part}
+		}
+	}
+	return segments
+}
+
+// cleanAuthorName removes the "By" prefix and punctuation around a name,
+// returning an empty string if it does not look like a name
+func cleanAuthorName(name string) string {
+	name = strings.TrimFunc(NormalizeText(name), isNameTrim)
+	for _, prefix := range bylinePrefixes {
+		if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			if rest := name[len(prefix):]; rest[0] == ' ' || rest[0] == ':' {
+				name = strings.TrimFunc(rest, isNameTrim)
+				break
+			}
+		}
+	}
+
+	words := strings.Fields(name)
+	if len(words) == 0 || slices.Contains(bylinePrefixes, strings.ToLower(name)) || len(words) > maxAuthorNameWords || len(name) > maxAuthorNameLength {
+		return ""
+	}
+	if strings.ContainsFunc(name, unicode.IsDigit) || !strings.ContainsFunc(name, unicode.IsLetter) {
+		return ""
+	}
+	for _, word := range words {
+		if bylineRoleWords[strings.ToLower(strings.TrimFunc(word, isNameTrim))] {
+			return ""
+		}
+	}
+	return name
+}
+
+// isNameTrim returns true for the characters trimmed around a name
+func isNameTrim(r rune) bool {
+	return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '.' && r != '\'') || unicode.IsSymbol(r)
+}
+
+// socialHandle returns the handle of a "@name" value or of a Twitter/X profile URL
+func socialHandle(value string) string {
+	value = strings.TrimSpace(value)
+	if handle, ok := strings.CutPrefix(value, "@"); ok {
+		if len(handle) > 0 && !strings.ContainsFunc(handle, unicode.IsSpace) {
+			return value
+		}
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || !socialHosts[strings.ToLower(u.Host)] {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if len(name) == 0 {
+		return ""
+	}
+	return "@" + name
+}
+
+// isAbsoluteURL returns true if value is an absolute http or https URL
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// attrValue returns the value of the attribute key of t, or an empty string
+func attrValue(t html.Token, key string) string {
+	for _, attr := range t.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
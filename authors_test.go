@@ -0,0 +1,199 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitByline tests finding the names in bylines
+func TestSplitByline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		byline   string
+		expected []string
+	}{
+		{"Jane Doe", []string{"Jane Doe"}},
+		{"By Jane Doe", []string{"Jane Doe"}},
+		{"  by:  Jane   Doe ", []string{"Jane Doe"}},
+		{"BY JANE DOE", []string{"JANE DOE"}},
+		{"Written by Jane Doe", []string{"Jane Doe"}},
+		{"By Jane Doe and John Smith", []string{"Jane Doe", "John Smith"}},
+		{"By Jane Doe, John Smith & Ana López", []string{"Jane Doe", "John Smith", "Ana López"}},
+		{"By Jane Doe, Staff Writer", []string{"Jane Doe"}},
+		{"By Doe, Jane", []string{"Doe, Jane"}},
+		{"Jane Doe, Jr.", []string{"Jane Doe, Jr."}},
+		{"Jane Doe, Jr. and John Smith", []string{"Jane Doe, Jr.", "John Smith"}},
+		{"Jane Doe, John Smith, May 12, 2024", []string{"Jane Doe", "John Smith"}},
+		{"Jane Doe | May 12, 2024", []string{"Jane Doe"}},
+		{"By Jane Doe · Updated 3 hours ago · 5 min read", []string{"Jane Doe"}},
+		{"Di Wang", []string{"Di Wang"}},
+		{"Von Miller", []string{"Von Miller"}},
+		{"Door Ryan", []string{"Door Ryan"}},
+		{"Diana Prince", []string{"Diana Prince"}},
+		{"By Jane Doe and Jane Doe", []string{"Jane Doe"}},
+		{"J.R.R. Tolkien", []string{"J.R.R. Tolkien"}},
+		{"Conan O'Brien", []string{"Conan O'Brien"}},
+		{"By", nil},
+		{"2024-05-12", nil},
+		{"Jane Doe is a reporter who has covered city hall for many years", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.byline, func(t *testing.T) {
+			assert.Equal(t, test.expected, splitByline(test.byline))
+		})
+	}
+}
+
+// TestSocialHandle tests reading handles from values and profile URLs
+func TestSocialHandle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"@jane", "@jane"},
+		{" @jane ", "@jane"},
+		{"https://twitter.com/jane", "@jane"},
+		{"https://x.com/jane/", "@jane"},
+		{"https://www.twitter.com/jane/status/1", "@jane"},
+		{"https://example.com/jane", ""},
+		{"https://x.com/", ""},
+		{"@", ""},
+		{"@jane doe", ""},
+		{"Jane", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, socialHandle(test.value))
+		})
+	}
+}
+
+// TestExtractWithOptionsAuthors tests assembling the authors from every source
+func TestExtractWithOptionsAuthors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected []Author
+	}{
+		{
+			name:     "meta author",
+			mockHTML: `<head><meta name="author" content="By Jane Doe"></head>`,
+			expected: []Author{{Name: "Jane Doe", Sources: []string{TagMetaAuthor}}},
+		},
+		{
+			name:     "names starting like a foreign byline",
+			mockHTML: `<head><meta name="author" content="Di Wang, Von Miller"></head>`,
+			expected: []Author{
+				{Name: "Di Wang", Sources: []string{TagMetaAuthor}},
+				{Name: "Von Miller", Sources: []string{TagMetaAuthor}},
+			},
+		},
+		{
+			name: "meta tags are merged",
+			mockHTML: `<head><meta name="author" content="Jane Doe">
+				<meta property="article:author" content="https://example.com/authors/jane">
+				<meta name="twitter:creator" content="@janedoe">
+				<meta property="og:author" content="jane doe"></head>`,
+			expected: []Author{{
+				Handle:  "@janedoe",
+				Name:    "Jane Doe",
+				Sources: []string{TagMetaAuthor, TagOGAuthor, TagArticleAuthor, TagTwitterCreator},
+				URL:     "https://example.com/authors/jane",
+			}},
+		},
+		{
+			name: "several authors keep their own details",
+			mockHTML: `<head><meta name="author" content="Jane Doe, John Smith">
+				<meta name="twitter:creator" content="@janedoe"></head>`,
+			expected: []Author{
+				{Name: "Jane Doe", Sources: []string{TagMetaAuthor}},
+				{Name: "John Smith", Sources: []string{TagMetaAuthor}},
+				{Handle: "@janedoe", Sources: []string{TagTwitterCreator}},
+			},
+		},
+		{
+			name: "json-ld objects and arrays come first",
+			mockHTML: `<head><meta name="author" content="John Smith">
+				<script type="application/ld+json">{"@type":"NewsArticle","author":[
+					{"@type":"Person","name":"Jane Doe","url":"https://example.com/jane","sameAs":["https://facebook.com/jane","https://x.com/janedoe"]},
+					{"@type":"Person","name":"John Smith"}],
+					"publisher":{"@type":"Organization","name":"Example News"}}</script></head>`,
+			expected: []Author{
+				{Handle: "@janedoe", Name: "Jane Doe", Sources: []string{AuthorSourceJSONLD}, URL: "https://example.com/jane"},
+				{Name: "John Smith", Sources: []string{AuthorSourceJSONLD, TagMetaAuthor}},
+			},
+		},
+		{
+			name: "json-ld graph references and strings",
+			mockHTML: `<head><script type="application/ld+json">{"@graph":[
+					{"@type":"Article","@id":"#article","author":{"@id":"#jane"},"creator":"By John Smith"},
+					{"@type":"Person","@id":"#jane","name":"Jane Doe"}]}</script></head>`,
+			expected: []Author{
+				{Name: "Jane Doe", Sources: []string{AuthorSourceJSONLD}},
+				{Name: "John Smith", Sources: []string{AuthorSourceJSONLD}},
+			},
+		},
+		{
+			name:     "rel author link",
+			mockHTML: `<head><link rel="author" href="https://example.com/jane"><meta name="author" content="Jane Doe"></head>`,
+			expected: []Author{{Name: "Jane Doe", Sources: []string{TagMetaAuthor, AuthorSourceRel}, URL: "https://example.com/jane"}},
+		},
+		{
+			name: "byline markup",
+			mockHTML: `<head></head><body><header><h1>Story</h1>
+				<p class="article-byline">By <a href="/authors/jane">Jane Doe</a> and <a href="/authors/john">John Smith</a><br>May 12, 2024</p>
+				</header><div class="comment-author">Troll</div></body>`,
+			expected: []Author{
+				{Name: "Jane Doe", Sources: []string{AuthorSourceByline}, URL: "/authors/jane"},
+				{Name: "John Smith", Sources: []string{AuthorSourceByline}, URL: "/authors/john"},
+			},
+		},
+		{
+			name:     "rel author anchor",
+			mockHTML: `<head></head><body><span>Posted by <a rel="author" href="/jane">Jane <b>Doe</b></a></span></body>`,
+			expected: []Author{{Name: "Jane Doe", Sources: []string{AuthorSourceRel}, URL: "/jane"}},
+		},
+		{
+			name: "itemprop author with a nested name",
+			mockHTML: `<head></head><body><div itemprop="author" itemscope itemtype="https://schema.org/Person">
+				<div><span itemprop="name">Jane Doe</span></div></div></body>`,
+			expected: []Author{{Name: "Jane Doe", Sources: []string{AuthorSourceItemprop}}},
+		},
+		{
+			name:     "nothing",
+			mockHTML: `<head><meta name="author" content=" "></head><body><p>Text</p></body>`,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{Authors: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.Authors)
+			assert.Nil(t, result.JSONLD)
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta name="author" content="Jane Doe"></head>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Authors)
+		assert.Equal(t, "Jane Doe", result.Author)
+	})
+
+	t.Run("the body is read until the first byline", func(t *testing.T) {
+		page := `<head></head><body><p class="byline">Jane Doe</p><p class="byline">John Smith</p></body>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Authors: true})
+		require.NotNil(t, result)
+		assert.Equal(t, []Author{{Name: "Jane Doe", Sources: []string{AuthorSourceByline}}}, result.Authors)
+	})
+}
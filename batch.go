@@ -0,0 +1,273 @@
+package metaextractor
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batch defaults
+const (
+	DefaultBatchConcurrency = 8
+	DefaultBatchCrawlDelay  = time.Second // Time between two requests to the same host with the default Fetcher
+	DefaultPerHostLimit     = 2
+)
+
+// BatchOptions are the options used by BatchExtract
+//
+// The time between two requests to the same host is left to the Fetcher, the
+// default one waits DefaultBatchCrawlDelay. Set the CrawlDelay or HostRate of
+// a custom Fetcher to space them out differently.
+type BatchOptions struct {
+	Concurrency  int             // Number of items processed at the same time
+	Extract      *ExtractOptions // Options used to extract every item (nil only extracts the Tags)
+	Fetcher      *Fetcher        // Fetcher used for URL items (defaults to one with a CrawlDelay of DefaultBatchCrawlDelay)
+	PerHostLimit int             // Maximum number of in-flight requests to a single host
+}
+
+// DefaultBatchOptions will return the default options for BatchExtract
+func DefaultBatchOptions() *BatchOptions {
+	return &BatchOptions{
+		Concurrency:  DefaultBatchConcurrency,
+		PerHostLimit: DefaultPerHostLimit,
+	}
+}
+
+// BatchItem is a single unit of work for BatchExtract
+//
+// If Reader is set it is parsed directly (and URL is only used as a label),
+// otherwise the URL is fetched. Readers are never closed by BatchExtract.
+type BatchItem struct {
+	Options *ExtractOptions // Options used to extract this item instead of BatchOptions.Extract
+	Reader  io.Reader
+	URL     string
+}
+
+// BatchResult is the outcome of extracting a single BatchItem
+type BatchResult struct {
+	Err    error   // Error fetching the item, if any
+	Index  int     // Position of the item in the input
+	Result *Result // Result of the extraction when it used options (nil otherwise, or if Err is set)
+	Tags   Tags    // Extracted tags (empty if Err is set)
+	URL    string  // URL of the item
+}
+
+// BatchExtract will fetch and extract every item received on items using a
+// bounded pool of workers, streaming back one BatchResult per item
+//
+// Results are delivered in completion order, use BatchResult.Index to correlate
+// them with the input. The returned channel is closed once items is closed and
+// drained, or once ctx is canceled (unprocessed items are then dropped).
+func BatchExtract(ctx context.Context, items <-chan BatchItem, options *BatchOptions) <-chan BatchResult {
+	options = batchDefaults(options)
+
+	type indexedItem struct {
+		BatchItem
+
+		index int
+	}
+
+	// Number the items in arrival order before handing them to the workers
+	work := make(chan indexedItem)
+	go func() {
+		defer close(work)
+		index := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok := <-items:
+				if !ok {
+					return
+				}
+				select {
+				case work <- indexedItem{BatchItem: item, index: index}:
+					index++
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	slots := newHostSlots(options.PerHostLimit)
+	results := make(chan BatchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				result := extractBatchItem(ctx, options, slots, item.BatchItem)
+				result.Index = item.index
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// BatchExtractURLs will fetch and extract all the given URLs concurrently
+//
+// The results are returned in the same order as urls
+func BatchExtractURLs(ctx context.Context, urls []string, options *BatchOptions) []BatchResult {
+	items := make(chan BatchItem)
+	go func() {
+		defer close(items)
+		for _, u := range urls {
+			select {
+			case items <- BatchItem{URL: u}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make([]BatchResult, 0, len(urls))
+	for result := range BatchExtract(ctx, items, options) {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Index < results[j].Index
+	})
+	return results
+}
+
+// batchDefaults returns a copy of options with any missing values replaced by defaults
+func batchDefaults(options *BatchOptions) *BatchOptions {
+	opts := DefaultBatchOptions()
+	if options != nil {
+		o := *options
+		if o.Concurrency <= 0 {
+			o.Concurrency = opts.Concurrency
+		}
+		if o.PerHostLimit <= 0 {
+			o.PerHostLimit = opts.PerHostLimit
+		}
+		opts = &o
+	}
+	if opts.Fetcher == nil {
+		defaults := DefaultFetcherOptions()
+		defaults.CrawlDelay = DefaultBatchCrawlDelay
+		opts.Fetcher = NewFetcher(defaults)
+	}
+	return opts
+}
+
+// extractBatchItem processes a single item, honoring the per-host limit for URL items
+func extractBatchItem(ctx context.Context, options *BatchOptions, slots *hostSlots, item BatchItem) BatchResult {
+	result := BatchResult{URL: item.URL}
+	extract := item.Options
+	if extract == nil {
+		extract = options.Extract
+	}
+	if item.Reader != nil {
+		if extract == nil {
+			result.Tags = Extract(item.Reader)
+		} else {
+			result.Result = ExtractWithOptions(item.Reader, *extract)
+			result.Tags = result.Result.Tags
+		}
+		return result
+	}
+
+	u, err := parseFetchURL(item.URL)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	var release func()
+	if release, err = slots.acquire(ctx, hostKey(u)); err != nil {
+		result.Err = err
+		return result
+	}
+	defer release()
+
+	if extract == nil {
+		result.Tags, result.Err = options.Fetcher.Extract(ctx, u.String())
+		return result
+	}
+	if result.Result, result.Err = options.Fetcher.ExtractWithOptions(ctx, u.String(), *extract); result.Result != nil {
+		result.Tags = result.Result.Tags
+	}
+	return result
+}
+
+// hostSlots limits the number of concurrent requests to each host, the time
+// between them is left to the Fetcher's rate limit and crawl delay
+//
+// A host is forgotten once its last request has finished, so batches of many
+// distinct hosts do not keep a semaphore for each of them
+type hostSlots struct {
+	hosts map[string]*hostSlot
+	limit int
+	mu    sync.Mutex
+}
+
+// hostSlot is the semaphore of a single host, bounded to the per-host limit
+type hostSlot struct {
+	sem   chan struct{}
+	users int // Requests holding or waiting for the semaphore
+}
+
+// newHostSlots will create a new hostSlots
+func newHostSlots(limit int) *hostSlots {
+	return &hostSlots{
+		hosts: make(map[string]*hostSlot),
+		limit: limit,
+	}
+}
+
+// acquire blocks until a request to host may start, the returned func must be
+// called once the request has finished
+func (s *hostSlots) acquire(ctx context.Context, host string) (func(), error) {
+	s.mu.Lock()
+	slot, ok := s.hosts[host]
+	if !ok {
+		slot = &hostSlot{sem: make(chan struct{}, s.limit)}
+		s.hosts[host] = slot
+	}
+	slot.users++
+	s.mu.Unlock()
+
+	select {
+	case slot.sem <- struct{}{}:
+		return func() {
+			<-slot.sem
+			s.leave(host, slot)
+		}, nil
+	case <-ctx.Done():
+		s.leave(host, slot)
+		return nil, ctx.Err()
+	}
+}
+
+// leave removes a user of a host's slot, forgetting the host after the last one
+func (s *hostSlots) leave(host string, slot *hostSlot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slot.users--; slot.users == 0 {
+		delete(s.hosts, host)
+	}
+}
+
+// hostKey returns the key used to group requests by host
+func hostKey(u *url.URL) string {
+	return strings.ToLower(u.Host)
+}
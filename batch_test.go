@@ -0,0 +1,208 @@
+package metaextractor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBatchExtractURLs will test extracting many URLs concurrently
+func TestBatchExtractURLs(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `<html><head><title>%s</title></head></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	urls := make([]string, 0, 25)
+	for i := 0; i < 24; i++ {
+		urls = append(urls, fmt.Sprintf("%s/page-%d", server.URL, i))
+	}
+	urls = append(urls, server.URL+"/missing")
+
+	results := BatchExtractURLs(context.Background(), urls, &BatchOptions{Concurrency: 4, Fetcher: NewFetcher(nil), PerHostLimit: 3})
+	require.Len(t, results, len(urls))
+
+	for i, result := range results[:24] {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, urls[i], result.URL)
+		require.NoError(t, result.Err)
+		assert.Equal(t, fmt.Sprintf("/page-%d", i), result.Tags.Title)
+	}
+	require.ErrorIs(t, results[24].Err, ErrUnexpectedStatus)
+	assert.Empty(t, results[24].Tags.Title)
+}
+
+// TestBatchExtractReaders will test extracting readers without fetching
+func TestBatchExtractReaders(t *testing.T) {
+	t.Parallel()
+
+	items := make(chan BatchItem, 3)
+	items <- BatchItem{Reader: strings.NewReader(`<title>one</title>`), URL: "file-one"}
+	items <- BatchItem{Reader: strings.NewReader(`<title>two</title>`), URL: "file-two"}
+	items <- BatchItem{URL: "not a url"}
+	close(items)
+
+	titles := make(map[int]string)
+	for result := range BatchExtract(context.Background(), items, nil) {
+		if result.Index == 2 {
+			require.ErrorIs(t, result.Err, ErrInvalidURL)
+			continue
+		}
+		require.NoError(t, result.Err)
+		titles[result.Index] = result.Tags.Title
+	}
+	assert.Equal(t, map[int]string{0: "one", 1: "two"}, titles)
+}
+
+// TestBatchExtractPerHostLimit will test that in-flight requests to a host are bounded
+func TestBatchExtractPerHostLimit(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			highest := atomic.LoadInt32(&maxInFlight)
+			if current <= highest || atomic.CompareAndSwapInt32(&maxInFlight, highest, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		_, _ = w.Write([]byte(`<title>ok</title>`))
+	}))
+	defer server.Close()
+
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/%d", server.URL, i)
+	}
+
+	results := BatchExtractURLs(context.Background(), urls, &BatchOptions{Concurrency: 10, Fetcher: NewFetcher(nil), PerHostLimit: 2})
+	require.Len(t, results, len(urls))
+	for _, result := range results {
+		require.NoError(t, result.Err)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+}
+
+// TestBatchExtractCanceled will test that a canceled context stops the batch
+func TestBatchExtractCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The items channel is never closed, only the context can end the batch
+	items := make(chan BatchItem)
+	count := 0
+	for range BatchExtract(ctx, items, nil) {
+		count++
+	}
+	assert.Zero(t, count)
+}
+
+// TestBatchExtractCrawlDelay will test that requests to the same host are spaced out by the fetcher
+func TestBatchExtractCrawlDelay(t *testing.T) {
+	t.Parallel()
+
+	first := newTestPageServer(t, `<title>first</title>`)
+	second := newTestPageServer(t, `<title>second</title>`)
+	clock := newFakeClock()
+	fetcher := NewFetcher(&FetcherOptions{Clock: clock, CrawlDelay: 2 * time.Second})
+
+	urls := []string{first.URL + "/1", first.URL + "/2", first.URL + "/3", second.URL}
+	results := BatchExtractURLs(context.Background(), urls, &BatchOptions{Concurrency: 1, Fetcher: fetcher})
+	require.Len(t, results, len(urls))
+	for _, result := range results {
+		require.NoError(t, result.Err)
+	}
+
+	// Other hosts are not delayed
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, clock.Sleeps())
+}
+
+// TestBatchDefaults will test that the default fetcher spaces out requests to the same host
+func TestBatchDefaults(t *testing.T) {
+	t.Parallel()
+
+	options := batchDefaults(nil)
+	assert.Equal(t, DefaultBatchConcurrency, options.Concurrency)
+	assert.Equal(t, DefaultPerHostLimit, options.PerHostLimit)
+	require.NotNil(t, options.Fetcher)
+	assert.Equal(t, DefaultBatchCrawlDelay, options.Fetcher.limiter.crawlDelay)
+
+	fetcher := NewFetcher(nil)
+	assert.Same(t, fetcher, batchDefaults(&BatchOptions{Fetcher: fetcher}).Fetcher)
+}
+
+// TestBatchExtractOptions will test extracting items with the batch and per-item options
+func TestBatchExtractOptions(t *testing.T) {
+	t.Parallel()
+
+	page := `<head><title>Page</title><meta name="author" content="Jane Doe"></head>`
+	server := newTestPageServer(t, page)
+
+	items := make(chan BatchItem, 3)
+	items <- BatchItem{Reader: strings.NewReader(page), URL: "batch-options"}
+	items <- BatchItem{Options: &ExtractOptions{}, Reader: strings.NewReader(page), URL: "item-options"}
+	items <- BatchItem{URL: server.URL}
+	close(items)
+
+	results := make(map[int]BatchResult)
+	options := &BatchOptions{Extract: &ExtractOptions{Authors: true}, Fetcher: NewFetcher(&FetcherOptions{Clock: newFakeClock()})}
+	for result := range BatchExtract(context.Background(), items, options) {
+		require.NoError(t, result.Err)
+		require.NotNil(t, result.Result)
+		assert.Equal(t, "Page", result.Tags.Title)
+		assert.Equal(t, result.Result.Tags, result.Tags)
+		results[result.Index] = result
+	}
+	require.Len(t, results, 3)
+	require.Len(t, results[0].Result.Authors, 1)
+	assert.Equal(t, "Jane Doe", results[0].Result.Authors[0].Name)
+	assert.Empty(t, results[1].Result.Authors)
+	require.Len(t, results[2].Result.Authors, 1)
+	assert.Equal(t, "Jane Doe", results[2].Result.Authors[0].Name)
+
+	t.Run("no options only extracts the tags", func(t *testing.T) {
+		results := BatchExtractURLs(context.Background(), []string{server.URL}, &BatchOptions{Fetcher: NewFetcher(&FetcherOptions{Clock: newFakeClock()})})
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+		assert.Nil(t, results[0].Result)
+		assert.Equal(t, "Page", results[0].Tags.Title)
+	})
+}
+
+// TestHostSlots will test that idle hosts are forgotten
+func TestHostSlots(t *testing.T) {
+	t.Parallel()
+
+	slots := newHostSlots(1)
+	release, err := slots.acquire(context.Background(), "a.example")
+	require.NoError(t, err)
+
+	// A canceled wait leaves the held slot alone
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = slots.acquire(ctx, "a.example")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, slots.hosts, 1)
+
+	release()
+	assert.Empty(t, slots.hosts)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+)
+
+// extractFlags are the flags of the extract command
+type extractFlags struct {
+	format       string
+	maxBodySize  int64
+	maxRefreshes int
+	options      metaextractor.ExtractOptions
+	robots       bool
+	timeout      time.Duration
+	userAgent    string
+}
+
+// document is the extraction result of a single input
+type document struct {
+	*metaextractor.Result
+
+	Error string `json:"error,omitempty"`
+	Input string `json:"input"`
+}
+
+// newExtractFlagSet creates the flag set of the extract command
+func newExtractFlagSet(stderr io.Writer, f *extractFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("metaextract", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: metaextract [flags] <file | url | ->...\n\n"+
+			"Extracts the title, description, OG & meta tags from HTML files, stdin (-) and URLs.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.BoolVar(&f.options.Alternates, "alternates", false, "collect the hreflang alternates")
+	fs.BoolVar(&f.options.AppLinks, "app-links", false, "collect the App Links and Facebook app tags")
+	fs.BoolVar(&f.options.Authors, "authors", false, "collect the authors from the meta tags, JSON-LD and bylines")
+	fs.BoolVar(&f.options.CleanTitle, "clean-title", false, "remove the site name from the title")
+	fs.BoolVar(&f.options.Dates, "dates", false, "find the publication and modification dates")
+	fs.BoolVar(&f.options.DetectLanguage, "detect-language", false, "detect the language of the title and description, even when one is declared")
+	fs.BoolVar(&f.options.Fallbacks, "fallbacks", false, "derive a missing description and image from the body content")
+	fs.StringVar(&f.format, "format", formatJSON, "output format: "+strings.Join(formatNames, ", "))
+	fs.BoolVar(&f.options.HTTPEquiv, "http-equiv", false, "collect the <meta http-equiv> values such as refresh and Content-Type")
+	fs.BoolVar(&f.options.Keywords, "keywords", false, "collect the keywords from the meta tags and JSON-LD")
+	fs.Int64Var(&f.maxBodySize, "max-body", metaextractor.DefaultMaxBodySize, "maximum number of bytes read from a URL")
+	fs.IntVar(&f.maxRefreshes, "max-refreshes", 0, "maximum number of meta refresh redirects followed when fetching URLs")
+	fs.BoolVar(&f.options.Normalize, "normalize", false, "collapse whitespace and remove invisible characters")
+	fs.BoolVar(&f.options.Platforms, "platforms", false, "collect the Pinterest, LinkedIn, VK and Slack tags")
+	fs.BoolVar(&f.options.Product, "product", false, "collect the price, availability and brand of product pages")
+	fs.BoolVar(&f.options.Provenance, "provenance", false, "record which tag supplied each field")
+	fs.BoolVar(&f.options.ScanBody, "scan-body", false, "keep reading after <body> to find metadata emitted late")
+	fs.IntVar(&f.options.ScanLimit, "scan-limit", 0, "maximum number of bytes read after <body> with -scan-body, -authors, -dates, -fallbacks, -keywords or -product (0 reads everything)")
+	fs.BoolVar(&f.options.StripTags, "strip-tags", false, "remove HTML tags left in the values")
+	fs.BoolVar(&f.robots, "robots", false, "respect robots.txt when fetching URLs")
+	fs.DurationVar(&f.timeout, "timeout", metaextractor.DefaultFetchTimeout, "timeout for fetching each URL")
+	fs.StringVar(&f.userAgent, "user-agent", metaextractor.DefaultUserAgent, "User-Agent sent when fetching URLs")
+	fs.BoolVar(&f.options.Warnings, "warnings", false, "report problems found in the metadata")
+	return fs
+}
+
+// runExtract runs the extract command
+func runExtract(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var f extractFlags
+	fs := newExtractFlagSet(stderr, &f)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	writer, ok := formatters[f.format]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown format %q, expected one of: %s\n", f.format, strings.Join(formatNames, ", "))
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	fetcher := metaextractor.NewFetcher(&metaextractor.FetcherOptions{
+		MaxBodySize:   f.maxBodySize,
+		MaxRefreshes:  f.maxRefreshes,
+		RespectRobots: f.robots,
+		UserAgent:     f.userAgent,
+	})
+
+	code := exitOK
+	docs := make([]document, 0, fs.NArg())
+	for _, input := range fs.Args() {
+		doc := document{Input: input}
+		var err error
+		if doc.Result, err = extractInput(ctx, fetcher, input, stdin, f); err != nil {
+			doc.Error = err.Error()
+			_, _ = fmt.Fprintf(stderr, "metaextract: %s: %v\n", input, err)
+			code = exitError
+		}
+		docs = append(docs, doc)
+	}
+
+	if err := writer(stdout, docs); err != nil {
+		_, _ = fmt.Fprintf(stderr, "metaextract: %v\n", err)
+		return exitError
+	}
+	return code
+}
+
+// extractInput extracts the metadata of a single input, which is a URL, a file or "-" for stdin
+func extractInput(ctx context.Context, fetcher *metaextractor.Fetcher, input string, stdin io.Reader,
+	f extractFlags,
+) (*metaextractor.Result, error) {
+	if isURL(input) {
+		ctx, cancel := context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+		return fetcher.ExtractWithOptions(ctx, input, f.options)
+	}
+
+	if input == "-" {
+		return metaextractor.ExtractWithOptions(stdin, f.options), nil
+	}
+
+	data, err := os.ReadFile(input) //nolint:gosec // reading the files named on the command line is the point
+	if err != nil {
+		return nil, err
+	}
+	return metaextractor.ExtractWithOptions(bytes.NewReader(data), f.options), nil
+}
+
+// isURL returns true if the input should be fetched instead of read from disk
+func isURL(input string) bool {
+	lower := strings.ToLower(input)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+)
+
+// Lint output formats
+const (
+	lintFormatGitHub = "github"
+	lintFormatJSON   = "json"
+	lintFormatSARIF  = "sarif"
+	lintFormatText   = "text"
+)
+
+// lintFormatters writes the lint issues in each output format
+var lintFormatters = map[string]func(w io.Writer, issues []lintIssue) error{
+	lintFormatGitHub: writeLintGitHub,
+	lintFormatJSON:   writeLintJSON,
+	lintFormatSARIF:  writeLintSARIF,
+	lintFormatText:   writeLintText,
+}
+
+// lintFormatNames are the names of the lint output formats, for usage messages
+var lintFormatNames = []string{lintFormatText, lintFormatJSON, lintFormatSARIF, lintFormatGitHub}
+
+// lintIssue is a validation finding (or extraction warning) in a single file
+type lintIssue struct {
+	metaextractor.Finding
+
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"` // Line of the tag the finding is about, zero if unknown
+}
+
+// lintFlags are the flags of the lint command
+type lintFlags struct {
+	extensions string
+	failOn     string
+	format     string
+	warnings   bool
+}
+
+// runLint runs the lint command, which validates the metadata of local HTML files
+func runLint(_ context.Context, args []string, stdout, stderr io.Writer) int {
+	var f lintFlags
+	flags := flag.NewFlagSet("metaextract lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: metaextract lint [flags] <file | directory>...\n\n"+
+			"Validates the metadata of HTML files, walking directories recursively.\n"+
+			"Exits with status 1 when a finding is at least as severe as -fail-on.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&f.extensions, "ext", ".html,.htm", "comma separated extensions of the files checked in directories")
+	flags.StringVar(&f.failOn, "fail-on", metaextractor.SeverityError.String(), "lowest severity that fails the lint: error, warning or info")
+	flags.StringVar(&f.format, "format", lintFormatText, "output format: "+strings.Join(lintFormatNames, ", "))
+	flags.BoolVar(&f.warnings, "warnings", true, "include problems found while extracting (duplicate tags, truncation, etc.)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	writer, ok := lintFormatters[f.format]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown format %q, expected one of: %s\n", f.format, strings.Join(lintFormatNames, ", "))
+		return exitUsage
+	}
+	var failOn metaextractor.Severity
+	if err := failOn.UnmarshalText([]byte(f.failOn)); err != nil {
+		_, _ = fmt.Fprintf(stderr, "unknown severity %q, expected one of: error, warning, info\n", f.failOn)
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	files, err := lintFiles(flags.Args(), strings.Split(f.extensions, ","))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "metaextract: %v\n", err)
+		return exitError
+	}
+
+	issues := make([]lintIssue, 0)
+	for _, file := range files {
+		var fileIssues []lintIssue
+		if fileIssues, err = lintFile(file, f.warnings); err != nil {
+			_, _ = fmt.Fprintf(stderr, "metaextract: %v\n", err)
+			return exitError
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	if err = writer(stdout, issues); err != nil {
+		_, _ = fmt.Fprintf(stderr, "metaextract: %v\n", err)
+		return exitError
+	}
+	for _, issue := range issues {
+		if issue.Severity >= failOn {
+			return exitError
+		}
+	}
+	return exitOK
+}
+
+// lintFiles expands the paths into the list of files to check, files named
+// directly are always checked while directories only contribute matching extensions
+func lintFiles(paths, extensions []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if !entry.IsDir() && hasExtension(file, extensions) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// hasExtension returns true if the file has one of the extensions (case-insensitive)
+func hasExtension(file string, extensions []string) bool {
+	ext := filepath.Ext(file)
+	for _, candidate := range extensions {
+		if candidate = strings.TrimSpace(candidate); len(candidate) > 0 && strings.EqualFold(ext, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintFile validates a single file
+func lintFile(file string, includeWarnings bool) ([]lintIssue, error) {
+	r, err := os.Open(file) //nolint:gosec // reading the files named on the command line is the point
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	result := metaextractor.ExtractWithOptions(r, metaextractor.ExtractOptions{
+		Provenance: true,
+		Warnings:   includeWarnings,
+	})
+
+	var issues []lintIssue
+	for _, warning := range result.Warnings {
+		issues = append(issues, lintIssue{
+			File: file,
+			Finding: metaextractor.Finding{
+				Field:    warning.Field,
+				Message:  warning.Message,
+				Rule:     string(warning.Code),
+				Severity: metaextractor.SeverityWarning,
+			},
+			Line: warning.Line,
+		})
+	}
+	for _, finding := range metaextractor.Validate(result) {
+		issues = append(issues, lintIssue{
+			File:    file,
+			Finding: finding,
+			Line:    result.Provenance[finding.Field].Line,
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// writeLintText writes one "file:line: severity: message (rule)" line per issue
+func writeLintText(w io.Writer, issues []lintIssue) error {
+	for _, issue := range issues {
+		location := issue.File
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s (%s)\n", location, issue.Severity, issue.Message, issue.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLintJSON writes the issues as an indented JSON array
+func writeLintJSON(w io.Writer, issues []lintIssue) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(issues)
+}
+
+// writeLintGitHub writes GitHub Actions workflow commands, which show up as annotations on pull requests
+func writeLintGitHub(w io.Writer, issues []lintIssue) error {
+	for _, issue := range issues {
+		command := "notice"
+		switch issue.Severity {
+		case metaextractor.SeverityError:
+			command = "error"
+		case metaextractor.SeverityWarning:
+			command = "warning"
+		case metaextractor.SeverityInfo:
+		}
+
+		properties := "file=" + escapeGitHubProperty(issue.File)
+		if issue.Line > 0 {
+			properties += fmt.Sprintf(",line=%d", issue.Line)
+		}
+		properties += ",title=" + escapeGitHubProperty(issue.Rule)
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, properties, escapeGitHubData(issue.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeGitHubData escapes the message of a workflow command
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html), only the parts we need
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+		Version string     `json:"version"`
+	}
+	sarifRun struct {
+		Results []sarifResult `json:"results"`
+		Tool    sarifTool     `json:"tool"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		InformationURI string      `json:"informationUri"`
+		Name           string      `json:"name"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		Level     string          `json:"level"`
+		Locations []sarifLocation `json:"locations"`
+		Message   sarifMessage    `json:"message"`
+		RuleID    string          `json:"ruleId"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+// writeLintSARIF writes the issues as a SARIF log, for code scanning tools
+func writeLintSARIF(w io.Writer, issues []lintIssue) error {
+	driver := sarifDriver{
+		InformationURI: "https://github.com/mrz1836/go-meta-extractor",
+		Name:           "metaextract",
+		Rules:          []sarifRule{},
+	}
+	results := make([]sarifResult, 0, len(issues))
+	seenRules := make(map[string]bool)
+
+	for _, issue := range issues {
+		if !seenRules[issue.Rule] {
+			seenRules[issue.Rule] = true
+			driver.Rules = append(driver.Rules, sarifRule{ID: issue.Rule})
+		}
+
+		level := "note"
+		switch issue.Severity {
+		case metaextractor.SeverityError:
+			level = "error"
+		case metaextractor.SeverityWarning:
+			level = "warning"
+		case metaextractor.SeverityInfo:
+		}
+
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(issue.File)},
+		}}
+		if issue.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: issue.Line}
+		}
+		results = append(results, sarifResult{
+			Level:     level,
+			Locations: []sarifLocation{location},
+			Message:   sarifMessage{Text: issue.Message},
+			RuleID:    issue.Rule,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{{Results: results, Tool: sarifTool{Driver: driver}}},
+		Version: "2.1.0",
+	})
+}
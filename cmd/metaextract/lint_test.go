@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	validLintPage = `<html><head>
+<title>Good Page</title>
+<meta name="description" content="A good description">
+<meta property="og:title" content="Good Page">
+<meta property="og:type" content="website">
+<meta property="og:url" content="https://example.com/">
+<meta property="og:image" content="https://example.com/image.png">
+<meta name="twitter:card" content="summary">
+</head></html>`
+
+	invalidLintPage = `<html><head>
+<title>Bad Page</title>
+<meta property="og:title" content="Bad Page">
+<meta property="og:type" content="website">
+<meta property="og:url" content="https://example.com/bad">
+<meta name="twitter:card" content="summary">
+</head></html>`
+)
+
+// writeSite creates a small static site and returns its root directory
+func writeSite(t *testing.T, pages map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range pages {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return root
+}
+
+// TestRunLint tests linting a static site
+func TestRunLint(t *testing.T) {
+	t.Parallel()
+
+	root := writeSite(t, map[string]string{
+		"index.html":           validLintPage,
+		"posts/bad.HTM":        invalidLintPage,
+		"posts/notes.txt":      invalidLintPage,
+		"posts/deep/good.html": validLintPage,
+	})
+	bad := filepath.Join(root, "posts", "bad.HTM")
+
+	t.Run("text output fails on errors", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "lint", root)
+		assert.Equal(t, exitError, code)
+		assert.Equal(t, bad+": error: og:image is required (og-required)\n"+
+			bad+": warning: page has no description (description-missing)\n", stdout)
+	})
+
+	t.Run("valid pages pass", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "lint", filepath.Join(root, "index.html"), filepath.Join(root, "posts", "deep"))
+		assert.Equal(t, exitOK, code)
+		assert.Empty(t, stdout)
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "lint", "-format", "json", root)
+		assert.Equal(t, exitError, code)
+
+		var issues []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(stdout), &issues))
+		require.Len(t, issues, 2)
+		assert.Equal(t, bad, issues[0]["file"])
+		assert.Equal(t, "og-required", issues[0]["rule"])
+		assert.Equal(t, "error", issues[0]["severity"])
+	})
+
+	t.Run("empty json output is an array", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "lint", "-format", "json", filepath.Join(root, "index.html"))
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "[]\n", stdout)
+	})
+
+	t.Run("sarif output", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "lint", "-format", "sarif", root)
+		assert.Equal(t, exitError, code)
+
+		var log sarifLog
+		require.NoError(t, json.Unmarshal([]byte(stdout), &log))
+		assert.Equal(t, "2.1.0", log.Version)
+		require.Len(t, log.Runs, 1)
+		assert.Equal(t, "metaextract", log.Runs[0].Tool.Driver.Name)
+		assert.Equal(t, []sarifRule{{ID: "og-required"}, {ID: "description-missing"}}, log.Runs[0].Tool.Driver.Rules)
+		require.Len(t, log.Runs[0].Results, 2)
+		assert.Equal(t, "error", log.Runs[0].Results[0].Level)
+		assert.Equal(t, filepath.ToSlash(bad), log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	})
+
+	t.Run("github output", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, "", "lint", "-format", "github", root)
+		assert.Equal(t, exitError, code)
+		assert.Contains(t, stdout, "::error file="+escapeGitHubProperty(bad)+",title=og-required::og:image is required\n")
+		assert.Contains(t, stdout, "::warning file="+escapeGitHubProperty(bad)+",title=description-missing::page has no description\n")
+	})
+
+	t.Run("fail on warnings", func(t *testing.T) {
+		page := strings.Replace(validLintPage, `content="A good description">`, `content="A good description"><meta name="description" content="Another">`, 1)
+		dir := writeSite(t, map[string]string{"index.html": page})
+
+		code, stdout, _ := runCommand(t, "", "lint", dir)
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, "index.html:3: warning: duplicate description overrides")
+
+		code, _, _ = runCommand(t, "", "lint", "-fail-on", "warning", dir)
+		assert.Equal(t, exitError, code)
+
+		code, stdout, _ = runCommand(t, "", "lint", "-warnings=false", "-fail-on", "warning", dir)
+		assert.Equal(t, exitOK, code)
+		assert.Empty(t, stdout)
+	})
+}
+
+// TestRunLintErrors tests invalid usage of the lint command
+func TestRunLintErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+		stderr   string
+	}{
+		{"no paths", []string{"lint"}, exitUsage, "Usage: metaextract lint"},
+		{"unknown format", []string{"lint", "-format", "xml", "."}, exitUsage, `unknown format "xml"`},
+		{"unknown severity", []string{"lint", "-fail-on", "fatal", "."}, exitUsage, `unknown severity "fatal"`},
+		{"help", []string{"lint", "-h"}, exitOK, "Usage: metaextract lint"},
+		{"missing path", []string{"lint", "does-not-exist"}, exitError, "does-not-exist"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, _, stderr := runCommand(t, "", test.args...)
+			assert.Equal(t, test.expected, code)
+			assert.Contains(t, stderr, test.stderr)
+		})
+	}
+}
+
+// TestEscapeGitHub tests escaping workflow command values
+func TestEscapeGitHub(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "100%25%0Adone", escapeGitHubData("100%\ndone"))
+	assert.Equal(t, "C%3A\\site%2Cnew", escapeGitHubProperty("C:\\site,new"))
+}
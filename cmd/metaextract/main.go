@@ -0,0 +1,43 @@
+// Package main is the metaextract command-line tool, which extracts the title,
+// description, OG & meta tags from HTML files, stdin and URLs
+//
+// Usage:
+//
+//	metaextract [flags] <file | url | ->...
+//	metaextract lint [flags] <file | directory>...
+//	metaextract serve [flags]
+//
+// Run "metaextract -h", "metaextract lint -h" or "metaextract serve -h" to see all the flags.
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// Exit codes
+const (
+	exitOK    = 0 // Everything worked
+	exitError = 1 // At least one input could not be extracted
+	exitUsage = 2 // Invalid flags or arguments
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command with the given arguments and returns the exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "extract":
+			return runExtract(ctx, args[1:], stdin, stdout, stderr)
+		case "lint":
+			return runLint(ctx, args[1:], stdout, stderr)
+		case "serve":
+			return runServe(ctx, args[1:], stdout, stderr)
+		}
+	}
+	return runExtract(ctx, args, stdin, stdout, stderr)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPage = `<html><head>
+<title>Test Title</title>
+<meta name="description" content="Test Description">
+<meta property="og:title" content="OG Title">
+<meta property="og:title" content="Other OG Title">
+</head></html>`
+
+// runCommand runs the command and returns the exit code, stdout and stderr
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeTestFile writes the test page to a temporary file and returns its path
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestRunExtractFormats tests each output format
+func TestRunExtractFormats(t *testing.T) {
+	t.Parallel()
+
+	path := writeTestFile(t, "page.html", testPage)
+
+	t.Run("json", func(t *testing.T) {
+		code, stdout, stderr := runCommand(t, "", "-provenance", path)
+		require.Equal(t, exitOK, code, stderr)
+
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal([]byte(stdout), &doc))
+		assert.Equal(t, path, doc["input"])
+		assert.Equal(t, "Test Title", doc["title"])
+		assert.Equal(t, "Other OG Title", doc["og_title"])
+		assert.Contains(t, doc, "provenance")
+	})
+
+	t.Run("json array for several inputs", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testPage, "extract", "-format", "json", path, "-")
+		require.Equal(t, exitOK, code)
+
+		var docs []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(stdout), &docs))
+		require.Len(t, docs, 2)
+		assert.Equal(t, "-", docs[1]["input"])
+		assert.Equal(t, "Test Description", docs[1]["description"])
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testPage, "-format", "ndjson", "-warnings", path, "-")
+		require.Equal(t, exitOK, code)
+
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		require.Len(t, lines, 2)
+		for _, line := range lines {
+			var doc map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &doc))
+			assert.Len(t, doc["warnings"], 1)
+		}
+	})
+
+	t.Run("kv", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testPage, "-format", "kv", "-warnings", "-")
+		require.Equal(t, exitOK, code)
+		assert.True(t, strings.HasPrefix(stdout, "input: -\n"))
+		assert.Contains(t, stdout, "\ntitle: Test Title\n")
+		assert.Contains(t, stdout, "\nwarnings.0.code: duplicate_tag\n")
+		assert.Contains(t, stdout, "\nwarnings.0.line: 5\n")
+		assert.NotContains(t, stdout, "twitter_title")
+	})
+
+	t.Run("table", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testPage, "-format", "table", "-", "-")
+		require.Equal(t, exitOK, code)
+		assert.Equal(t, 2, strings.Count(stdout, "FIELD"))
+		assert.Regexp(t, `\ndescription +Test Description\n`, stdout)
+	})
+}
+
+// TestRunExtractURL tests extracting a URL
+func TestRunExtractURL(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testPage))
+	}))
+	defer server.Close()
+
+	code, stdout, _ := runCommand(t, "", "-format", "kv", server.URL)
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "title: Test Title\n")
+
+	code, stdout, stderr := runCommand(t, "", "-format", "ndjson", server.URL+"/missing", server.URL)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "unexpected http status code: 404")
+	assert.Contains(t, stdout, `"error":"unexpected http status code: 404`)
+	assert.Contains(t, stdout, `"title":"Test Title"`)
+}
+
+// TestRunExtractErrors tests invalid usage and failing inputs
+func TestRunExtractErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+		stderr   string
+	}{
+		{"no inputs", []string{}, exitUsage, "Usage: metaextract"},
+		{"unknown format", []string{"-format", "xml", "-"}, exitUsage, `unknown format "xml"`},
+		{"unknown flag", []string{"-nope", "-"}, exitUsage, "flag provided but not defined"},
+		{"help", []string{"-h"}, exitOK, "Usage: metaextract"},
+		{"missing file", []string{"does-not-exist.html"}, exitError, "does-not-exist.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, _, stderr := runCommand(t, "", test.args...)
+			assert.Equal(t, test.expected, code)
+			assert.Contains(t, stderr, test.stderr)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// Output formats
+const (
+	formatJSON   = "json"
+	formatKV     = "kv"
+	formatNDJSON = "ndjson"
+	formatTable  = "table"
+)
+
+// formatters writes the documents in each output format
+var formatters = map[string]func(w io.Writer, docs []document) error{
+	formatJSON:   writeJSON,
+	formatKV:     writeKV,
+	formatNDJSON: writeNDJSON,
+	formatTable:  writeTable,
+}
+
+// formatNames are the names of the output formats, for usage messages
+var formatNames = []string{formatJSON, formatNDJSON, formatKV, formatTable}
+
+// writeJSON writes a single indented JSON object, or an array when there are several documents
+func writeJSON(w io.Writer, docs []document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if len(docs) == 1 {
+		return encoder.Encode(docs[0])
+	}
+	return encoder.Encode(docs)
+}
+
+// writeNDJSON writes one compact JSON object per line
+func writeNDJSON(w io.Writer, docs []document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeKV writes YAML-like "key: value" lines, with a blank line between
+// documents and the values that would break a line quoted (see kvValue)
+func writeKV(w io.Writer, docs []document) error {
+	for i, doc := range docs {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		pairs, err := flatten(doc)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			if _, err = fmt.Fprintf(w, "%s: %s\n", pair[0], kvValue(pair[1])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeTable writes an aligned two column table per document
+func writeTable(w io.Writer, docs []document) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, doc := range docs {
+		if i > 0 {
+			_, _ = fmt.Fprintln(tw)
+		}
+		pairs, err := flatten(doc)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(tw, "FIELD\tVALUE\n")
+		for _, pair := range pairs {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", pair[0], singleLine(pair[1]))
+		}
+	}
+	return tw.Flush()
+}
+
+// flatten turns a document into sorted key/value pairs, nested objects and
+// arrays use dotted keys (e.g. "provenance.title.source") and empty values are skipped
+func flatten(doc document) ([][2]string, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers are kept as they are written, so large offsets are not printed as floats
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]string
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch typed := v.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(typed))
+			for key := range typed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(joinKey(prefix, key), typed[key])
+			}
+		case []any:
+			for i, item := range typed {
+				walk(joinKey(prefix, strconv.Itoa(i)), item)
+			}
+		case string:
+			if len(typed) > 0 {
+				pairs = append(pairs, [2]string{prefix, typed})
+			}
+		case json.Number:
+			pairs = append(pairs, [2]string{prefix, typed.String()})
+		case nil:
+		default:
+			pairs = append(pairs, [2]string{prefix, fmt.Sprint(typed)})
+		}
+	}
+	walk("", value)
+
+	// The input always comes first so documents are easy to tell apart
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i][0] == "input" && pairs[j][0] != "input"
+	})
+	return pairs, nil
+}
+
+// joinKey joins two parts of a flattened key
+func joinKey(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// kvValue quotes a value holding line breaks or other control characters, so
+// it stays on one line, and values starting with a quote so they are not ambiguous
+func kvValue(s string) string {
+	if strings.HasPrefix(s, `"`) || strings.ContainsFunc(s, unicode.IsControl) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// singleLine collapses line breaks and tabs so a value fits in one table cell
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+)
+
+// TestFlatten tests turning a document into key/value pairs
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	result := &metaextractor.Result{
+		Provenance: map[string]metaextractor.Provenance{
+			metaextractor.FieldTitle: {Line: 40213, Offset: 1234567, Source: metaextractor.TagTitle},
+		},
+	}
+	result.Title = "Test Title"
+
+	pairs, err := flatten(document{Input: "page.html", Result: result})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]string{
+		{"input", "page.html"},
+		{"provenance.title.line", "40213"},
+		{"provenance.title.offset", "1234567"},
+		{"provenance.title.source", "title"},
+		{"provenance.title.truncated", "false"},
+		{"title", "Test Title"},
+	}, pairs)
+}
+
+// TestWriteKV tests that every value is written on a single line
+func TestWriteKV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"Test Title", "title: Test Title\n"},
+		{"Line one\nline: two", "title: \"Line one\\nline: two\"\n"},
+		{"Tab\tand\r\nbreak", "title: \"Tab\\tand\\r\\nbreak\"\n"},
+		{`"Quoted" title`, "title: \"\\\"Quoted\\\" title\"\n"},
+		{`Title "with" quotes`, "title: Title \"with\" quotes\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			result := &metaextractor.Result{}
+			result.Title = test.title
+
+			var buf bytes.Buffer
+			require.NoError(t, writeKV(&buf, []document{{Result: result}}))
+			assert.Contains(t, buf.String(), test.expected)
+		})
+	}
+}
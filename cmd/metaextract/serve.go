@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+	"github.com/mrz1836/go-meta-extractor/server"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
+// serveFlags are the flags of the serve command
+type serveFlags struct {
+	addr         string
+	allowPrivate bool
+	bodyLimit    int64
+	concurrency  int
+	maxRefreshes int
+	robots       bool
+	timeout      time.Duration
+	userAgent    string
+}
+
+// runServe runs the serve command until ctx is canceled or SIGINT/SIGTERM is received
+func runServe(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var f serveFlags
+	flags := flag.NewFlagSet("metaextract serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: metaextract serve [flags]\n\n"+
+			"Serves GET /extract?url=, POST /extract, /healthz and /metrics over HTTP.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&f.addr, "addr", ":8080", "address to listen on")
+	flags.BoolVar(&f.allowPrivate, "allow-private", false, "allow fetching loopback, private and link-local addresses")
+	flags.Int64Var(&f.bodyLimit, "body-limit", server.DefaultBodyLimit, "maximum size in bytes of a POST body")
+	flags.IntVar(&f.concurrency, "concurrency", server.DefaultConcurrency, "maximum number of extractions running at once")
+	flags.IntVar(&f.maxRefreshes, "max-refreshes", 0, "maximum number of meta refresh redirects followed when fetching URLs")
+	flags.BoolVar(&f.robots, "robots", false, "respect robots.txt when fetching URLs")
+	flags.DurationVar(&f.timeout, "timeout", server.DefaultTimeout, "time allowed for each extraction")
+	flags.StringVar(&f.userAgent, "user-agent", metaextractor.DefaultUserAgent, "User-Agent sent when fetching URLs")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	s := server.New(&server.Options{
+		AllowPrivateAddresses: f.allowPrivate,
+		BodyLimit:             f.bodyLimit,
+		Concurrency:           f.concurrency,
+		FetcherOptions: &metaextractor.FetcherOptions{
+			MaxRefreshes:  f.maxRefreshes,
+			RespectRobots: f.robots,
+			UserAgent:     f.userAgent,
+		},
+		Timeout: f.timeout,
+	})
+	httpServer := s.HTTPServer(f.addr)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", f.addr)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	_, _ = fmt.Fprintf(stdout, "listening on %s\n", listener.Addr())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err = <-errs:
+		_, _ = fmt.Fprintln(stderr, err)
+		return exitError
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	if err = httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		_, _ = fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	return exitOK
+}
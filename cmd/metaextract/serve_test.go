@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer that can be written and read from different goroutines
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+// Write will append p to the buffer
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String will return the contents of the buffer
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestRunServe tests serving requests and shutting down when the context is canceled
+func TestRunServe(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stdout, stderr syncBuffer
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"serve", "-addr", "127.0.0.1:0"}, nil, &stdout, &stderr)
+	}()
+
+	var addr string
+	require.Eventually(t, func() bool {
+		addr = strings.TrimSpace(strings.TrimPrefix(stdout.String(), "listening on "))
+		return len(addr) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+addr+"/extract", strings.NewReader(testPage))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"title":"Test Title"`)
+
+	// The server is on a loopback address, which it refuses to fetch
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/extract?url=http://"+addr+"/healthz", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	cancel()
+	assert.Equal(t, exitOK, <-done)
+	assert.Empty(t, stderr.String())
+}
+
+// TestRunServeErrors tests invalid serve arguments
+func TestRunServeErrors(t *testing.T) {
+	t.Parallel()
+
+	code, _, stderr := runCommand(t, "", "serve", "extra")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "Usage: metaextract serve")
+
+	code, _, _ = runCommand(t, "", "serve", "-nope")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, stderr = runCommand(t, "", "serve", "-addr", "256.0.0.1:bad")
+	assert.Equal(t, exitError, code)
+	assert.NotEmpty(t, stderr)
+}
@@ -0,0 +1,431 @@
+package metaextractor
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// ErrInvalidDate is returned by ParseDate when a value is not a date in any known format
+var ErrInvalidDate = errors.New("invalid date")
+
+// DateKind is what a date refers to
+type DateKind string
+
+// Date kinds
+const (
+	DateModified  DateKind = "modified"
+	DatePublished DateKind = "published"
+)
+
+// Date sources that are not a meta tag
+const (
+	DateSourceJSONLD = "json-ld" // datePublished, dateCreated, uploadDate or dateModified of the main JSON-LD node
+	DateSourceTime   = "time"    // <time datetime="..."> in the document
+	DateSourceURL    = "url"     // Date in the path of the page URL (e.g. /2024/05/12/)
+)
+
+// Date limits, candidates outside of them are ignored
+const (
+	MaxDateAhead = 48 * time.Hour // Dates later than now by more than this are typos or event dates
+	MinDateYear  = 1991           // Nothing was published on the web before
+)
+
+// Dates are the publication and modification dates found by the Dates option
+type Dates struct {
+	Candidates []Date `json:"candidates"`          // Every date found, in document order
+	Modified   *Date  `json:"modified,omitempty"`  // Best modification date (never before the publication date)
+	Published  *Date  `json:"published,omitempty"` // Best publication date
+}
+
+// Date is a date found in the document
+type Date struct {
+	Confidence float64   `json:"confidence"` // From 0 to 1, based on the source and how many other sources agree
+	Kind       DateKind  `json:"kind"`
+	Raw        string    `json:"raw"`    // Value as written in the document
+	Source     string    `json:"source"` // Meta tag (e.g. "article:published_time") or one of the DateSource constants
+	Time       time.Time `json:"time"`   // In UTC when the value has no time zone
+}
+
+// dateSource is how much a source of dates is trusted, and what kind of date it holds
+type dateSource struct {
+	confidence float64
+	kind       DateKind
+}
+
+// dateMetaTags are the meta names, properties and itemprops holding dates (lowercase)
+var dateMetaTags = map[string]dateSource{
+	"article:modified_time":  {0.9, DateModified},
+	"article:published_time": {0.9, DatePublished},
+	"datemodified":           {0.8, DateModified},
+	"datepublished":          {0.8, DatePublished},
+	"dc.date":                {0.7, DatePublished},
+	"dc.date.issued":         {0.75, DatePublished},
+	"dc.date.modified":       {0.7, DateModified},
+	"dcterms.created":        {0.7, DatePublished},
+	"dcterms.date":           {0.7, DatePublished},
+	"dcterms.issued":         {0.75, DatePublished},
+	"dcterms.modified":       {0.7, DateModified},
+	"og:published_time":      {0.85, DatePublished},
+	"og:updated_time":        {0.8, DateModified},
+	"parsely-pub-date":       {0.85, DatePublished},
+	"pubdate":                {0.7, DatePublished},
+	"sailthru.date":          {0.8, DatePublished},
+}
+
+// Confidence of the sources that are not meta tags
+const (
+	dateConfidenceJSONLD      = 0.9
+	dateConfidenceJSONLDOther = 0.7 // dateCreated, or any date of a node that is not the main one
+	dateConfidenceTime        = 0.5 // A <time> may date a comment or a related story
+	dateConfidenceTimeMarked  = 0.8 // A <time> marked with pubdate or itemprop
+	dateConfidenceURL         = 0.4
+	dateAgreementBonus        = 0.1 // For each other source agreeing on the same day
+	dateDisagreementPenalty   = 0.2 // When a trusted source gives another day
+)
+
+// dateLayouts are the formats tried by ParseDate, after the value has been cleaned up
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04Z0700",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006.01.02",
+	"20060102",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006",
+	time.RFC850,
+	time.ANSIC,
+	time.UnixDate,
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"2 January 2006 15:04",
+	"2 January 2006",
+	"Jan 2, 2006 3:04 PM MST",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006 15:04",
+	"Jan 2, 2006",
+	"January 2, 2006 3:04 PM MST",
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Monday, January 2, 2006 3:04 PM",
+	"Monday, January 2, 2006",
+	"Mon, Jan 2, 2006",
+	"January 2006",
+}
+
+// ParseDate will parse a date written in one of the many formats found on
+// the web: ISO 8601 and its variants, RFC 1123, "May 12, 2024 at 2:30 pm",
+// "12th May 2024" or a Unix timestamp (in seconds or milliseconds)
+//
+// Values without a time zone are in UTC
+func ParseDate(value string) (time.Time, error) {
+	value = cleanDate(value)
+	if len(value) == 0 {
+		return time.Time{}, ErrInvalidDate
+	}
+	if t, ok := parseTimestamp(value); ok {
+		return t, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidDate
+}
+
+// cleanDate rewrites a date into a form matching one of the dateLayouts:
+// ordinal suffixes, the word "at", dots after abbreviations and lowercase
+// am/pm are removed or fixed, and whitespace is collapsed
+func cleanDate(value string) string {
+	words := strings.Fields(value)
+	cleaned := make([]string, 0, len(words)+1)
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		switch {
+		case lower == "at" || lower == "on" || lower == "|" || lower == "-" && len(cleaned) > 0:
+			continue
+		case lower == "am" || lower == "pm" || lower == "a.m." || lower == "p.m.":
+			word = strings.ToUpper(strings.ReplaceAll(lower, ".", ""))
+		case strings.HasSuffix(lower, "am") || strings.HasSuffix(lower, "pm"):
+			if _, err := strconv.Atoi(strings.ReplaceAll(lower[:len(lower)-2], ":", "")); err == nil {
+				cleaned = append(cleaned, word[:len(word)-2])
+				word = strings.ToUpper(lower[len(lower)-2:])
+			}
+		default:
+			if word = strings.TrimSuffix(trimOrdinal(word), "."); strings.EqualFold(word, "sept") {
+				word = "Sep"
+			}
+		}
+		cleaned = append(cleaned, word)
+	}
+	return strings.Join(cleaned, " ")
+}
+
+// trimOrdinal removes the suffix of an ordinal day (e.g. "12th," becomes "12,")
+func trimOrdinal(word string) string {
+	digits := strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) })
+	if digits <= 0 || digits > 2 {
+		return word
+	}
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		if rest, ok := strings.CutPrefix(strings.ToLower(word[digits:]), suffix); ok {
+			return word[:digits] + rest
+		}
+	}
+	return word
+}
+
+// parseTimestamp parses a Unix timestamp in seconds (10 digits) or milliseconds (13 digits)
+func parseTimestamp(value string) (time.Time, bool) {
+	if len(value) != 10 && len(value) != 13 {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if len(value) == 13 {
+		return time.UnixMilli(n).UTC(), true
+	}
+	return time.Unix(n, 0).UTC(), true
+}
+
+// addDate adds a date candidate if it can be parsed and is within the date limits
+func (e *extractor) addDate(kind DateKind, source, raw string, confidence float64) bool {
+	raw = strings.TrimSpace(raw)
+	t, err := ParseDate(raw)
+	if err != nil || !isPlausibleDate(t, e.now) {
+		return false
+	}
+	e.dates = append(e.dates, Date{
+		Confidence: confidence,
+		Kind:       kind,
+		Raw:        truncateField(raw, MaxFieldLength),
+		Source:     source,
+		Time:       t,
+	})
+	return true
+}
+
+// isPlausibleDate returns true if t is neither before the web nor in the future of now
+func isPlausibleDate(t, now time.Time) bool {
+	return t.Year() >= MinDateYear && t.Before(now.Add(MaxDateAhead))
+}
+
+// addMetaDate adds the date of a meta tag holding one, warning when it cannot be parsed
+func (e *extractor) addMetaDate(t html.Token) {
+	key, content := "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName, TagProperty, TagItemprop:
+			if _, ok := dateMetaTags[strings.ToLower(attr.Val)]; ok {
+				key = strings.ToLower(attr.Val)
+			}
+		case TagContent:
+			content = attr.Val
+		}
+	}
+	source, ok := dateMetaTags[key]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return
+	}
+	if !e.addDate(source.kind, key, content, source.confidence) && e.options.Warnings {
+		e.warn(WarningInvalidDate, "", key, "date "+strconv.Quote(content)+" is not valid")
+	}
+}
+
+// addTimeDate adds the date of a <time datetime="..."> element, which is
+// trusted more when it is marked as the publication or modification date
+func (e *extractor) addTimeDate(t html.Token) {
+	datetime, itemprop, pubdate := "", "", false
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagDatetime:
+			datetime = attr.Val
+		case TagItemprop:
+			itemprop = strings.ToLower(attr.Val)
+		case TagPubdate:
+			pubdate = true
+		}
+	}
+	if len(strings.TrimSpace(datetime)) == 0 {
+		return
+	}
+
+	kind, confidence := DatePublished, dateConfidenceTime
+	switch {
+	case itemprop == "datemodified":
+		kind, confidence = DateModified, dateConfidenceTimeMarked
+	case itemprop == "datepublished" || pubdate:
+		confidence = dateConfidenceTimeMarked
+	}
+	if e.addDate(kind, DateSourceTime, datetime, confidence) && kind == DatePublished && confidence == dateConfidenceTimeMarked {
+		e.datesFound = true
+	}
+}
+
+// addJSONLDDates adds the dates of the JSON-LD nodes, the ones of the main
+// node (e.g. the NewsArticle rather than its publisher) being trusted more
+func (e *extractor) addJSONLDDates() {
+	keys := []struct {
+		key  string
+		kind DateKind
+	}{
+		{"datePublished", DatePublished},
+		{"uploadDate", DatePublished},
+		{"dateCreated", DatePublished},
+		{"dateModified", DateModified},
+	}
+	for _, node := range jsonLDNodes(e.jsonLD) {
+		main := jsonLDIsMain(node)
+		for _, k := range keys {
+			raw := jsonLDString(node[k.key])
+			if len(raw) == 0 {
+				continue
+			}
+			confidence := dateConfidenceJSONLD
+			if !main || k.key == "dateCreated" {
+				confidence = dateConfidenceJSONLDOther
+			}
+			e.addDate(k.kind, DateSourceJSONLD, raw, confidence)
+		}
+	}
+}
+
+// addURLDate adds the date found in the path of the page URL (or og:url)
+func (e *extractor) addURLDate() {
+	for _, rawURL := range []string{e.options.URL, e.result.OGURL} {
+		u, err := url.Parse(strings.TrimSpace(rawURL))
+		if err != nil || len(u.Path) == 0 {
+			continue
+		}
+		if raw, ok := urlPathDate(u.Path); ok {
+			e.addDate(DatePublished, DateSourceURL, raw, dateConfidenceURL)
+			return
+		}
+	}
+}
+
+// urlPathDate finds a date in a URL path, either as /2024/05/12/ or as a
+// segment starting with 2024-05-12, returning it as 2024-05-12
+func urlPathDate(path string) (string, bool) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if i+2 < len(segments) && isDatePart(segment, 4, MinDateYear, 2100) &&
+			isDatePart(segments[i+1], 2, 1, 12) && isDatePart(segments[i+2], 2, 1, 31) {
+			return segment + "-" + segments[i+1] + "-" + segments[i+2], true
+		}
+		if len(segment) >= 10 && segment[4] == '-' && segment[7] == '-' &&
+			isDatePart(segment[:4], 4, MinDateYear, 2100) && isDatePart(segment[5:7], 2, 1, 12) &&
+			isDatePart(segment[8:10], 2, 1, 31) && (len(segment) == 10 || !unicode.IsDigit(rune(segment[10]))) {
+			return segment[:10], true
+		}
+	}
+	return "", false
+}
+
+// isDatePart returns true if s is a number of the given length between lo and hi
+func isDatePart(s string, length, lo, hi int) bool {
+	if len(s) != length {
+		return false
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= lo && n <= hi
+}
+
+// pickDates adds the JSON-LD and URL dates to the candidates found in the
+// tags, then picks the best publication and modification dates
+func (e *extractor) pickDates() {
+	e.addJSONLDDates()
+	e.addURLDate()
+
+	dates := &Dates{Candidates: e.dates}
+	if dates.Candidates == nil {
+		dates.Candidates = []Date{}
+	}
+	dates.Published = bestDate(dates.Candidates, DatePublished, time.Time{})
+	after := time.Time{}
+	if dates.Published != nil {
+		after = dates.Published.Time
+	}
+	dates.Modified = bestDate(dates.Candidates, DateModified, after)
+	e.result.Dates = dates
+}
+
+// bestDate returns the most trusted date of a kind that is not before after,
+// its confidence raised by the other sources agreeing on the same day and
+// lowered when a trusted source disagrees
+func bestDate(candidates []Date, kind DateKind, after time.Time) *Date {
+	var matching []Date
+	for _, c := range candidates {
+		if c.Kind == kind && !c.Time.Before(after) {
+			matching = append(matching, c)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+
+	// The most trusted first, then the most precise (a time rather than midnight)
+	sort.SliceStable(matching, func(i, j int) bool {
+		if matching[i].Confidence != matching[j].Confidence {
+			return matching[i].Confidence > matching[j].Confidence
+		}
+		return hasTimeOfDay(matching[i].Time) && !hasTimeOfDay(matching[j].Time)
+	})
+
+	best := matching[0]
+	sources := map[string]bool{best.Source: true}
+	confidence := best.Confidence
+	for _, c := range matching[1:] {
+		if sources[c.Source] {
+			continue
+		}
+		sources[c.Source] = true
+		switch {
+		case sameDay(best.Time, c.Time):
+			confidence += dateAgreementBonus
+		case c.Confidence >= best.Confidence-dateAgreementBonus:
+			confidence -= dateDisagreementPenalty
+		}
+	}
+	best.Confidence = math.Round(min(max(confidence, 0), 1)*100) / 100
+	return &best
+}
+
+// hasTimeOfDay returns true if t is not exactly midnight, as parsed from a date without a time
+func hasTimeOfDay(t time.Time) bool {
+	return t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0
+}
+
+// sameDay returns true if a and b are less than a day apart, allowing for time zones
+func sameDay(a, b time.Time) bool {
+	return a.Sub(b).Abs() < 24*time.Hour
+}
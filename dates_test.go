@@ -0,0 +1,328 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseDate tests parsing the date formats found on the web
+func TestParseDate(t *testing.T) {
+	t.Parallel()
+
+	edt := time.FixedZone("", -4*60*60)
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-05-12T14:30:00Z", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"2024-05-12T14:30:00-04:00", time.Date(2024, 5, 12, 14, 30, 0, 0, edt)},
+		{"2024-05-12T14:30:00.123+00:00", time.Date(2024, 5, 12, 14, 30, 0, 123000000, time.UTC)},
+		{"2024-05-12T14:30:00-0400", time.Date(2024, 5, 12, 14, 30, 0, 0, edt)},
+		{"2024-05-12T14:30:00", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"2024-05-12T14:30-04:00", time.Date(2024, 5, 12, 14, 30, 0, 0, edt)},
+		{"2024-05-12 14:30:00", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"2024-05-12 14:30:00 -0400", time.Date(2024, 5, 12, 14, 30, 0, 0, edt)},
+		{"2024-05-12 14:30", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{" 2024-05-12 ", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"2024/05/12", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"2024.05.12", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"20240512", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"Sun, 12 May 2024 14:30:00 -0400", time.Date(2024, 5, 12, 14, 30, 0, 0, edt)},
+		{"Sun, 12 May 2024 14:30:00 GMT", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"12 May 2024", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"12th May 2024", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"May 12, 2024", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"May 12th, 2024", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"Sept. 3, 2024", time.Date(2024, 9, 3, 0, 0, 0, 0, time.UTC)},
+		{"Jan. 2, 2024", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"May 12, 2024 at 2:30 pm", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"May 12, 2024 2:30PM", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"May 12, 2024 2:30 p.m.", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"Sunday, May 12, 2024", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{"May 2024", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"1715524200", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+		{"1715524200000", time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			parsed, err := ParseDate(test.value)
+			require.NoError(t, err)
+			assert.True(t, test.expected.Equal(parsed), "expected %s, got %s", test.expected, parsed)
+		})
+	}
+
+	for _, value := range []string{"", "yesterday", "12/05/2024", "2024-13-01", "Posted by Jane", "123456"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			_, err := ParseDate(value)
+			require.ErrorIs(t, err, ErrInvalidDate)
+		})
+	}
+}
+
+// TestURLPathDate tests finding dates in URL paths
+func TestURLPathDate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/2024/05/12/city-approves-bike-lanes/", "2024-05-12"},
+		{"/news/2024/05/12/story.html", "2024-05-12"},
+		{"/blog/2024-05-12-city-approves-bike-lanes", "2024-05-12"},
+		{"/2024-05-12", "2024-05-12"},
+		{"/2024/05/", ""},
+		{"/2024/13/12/story", ""},
+		{"/1980/05/12/story", ""},
+		{"/products/2024-05-123", ""},
+		{"/story-12345", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			date, ok := urlPathDate(test.path)
+			assert.Equal(t, len(test.expected) > 0, ok)
+			assert.Equal(t, test.expected, date)
+		})
+	}
+}
+
+// TestExtractWithOptionsDates tests gathering date candidates and picking the best ones
+func TestExtractWithOptionsDates(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><head>
+		<meta property="article:published_time" content="2024-05-12T14:30:00Z">
+		<meta property="og:updated_time" content="2024-05-13T09:00:00Z">
+		<meta name="parsely-pub-date" content="2024-05-12T14:30:00Z">
+		<meta name="sailthru.date" content="Sun, 12 May 2024 14:30:00 +0000">
+		<meta name="DC.date" content="2024-05-12">
+		<meta property="og:url" content="https://example.com/2024/05/12/story/">
+		<script type="application/ld+json">{"@type":"NewsArticle","datePublished":"2024-05-12T14:30:00Z","dateModified":"2024-05-13T09:00:00Z",
+			"publisher":{"@type":"Organization","name":"Example"}}</script>
+	</head><body>
+		<article><time datetime="2024-05-12T14:30:00Z" pubdate>May 12</time></article>
+	</body></html>`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Dates)
+		assert.Nil(t, result.JSONLD)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Dates: true})
+		require.NotNil(t, result)
+		require.NotNil(t, result.Dates)
+		assert.Nil(t, result.JSONLD, "JSON-LD is only read for the dates")
+
+		sources := make([]string, 0, len(result.Dates.Candidates))
+		for _, c := range result.Dates.Candidates {
+			sources = append(sources, string(c.Kind)+" "+c.Source)
+		}
+		assert.Equal(t, []string{
+			"published article:published_time",
+			"modified og:updated_time",
+			"published parsely-pub-date",
+			"published sailthru.date",
+			"published dc.date",
+			"published time",
+			"published json-ld",
+			"modified json-ld",
+			"published url",
+		}, sources)
+
+		require.NotNil(t, result.Dates.Published)
+		assert.Equal(t, "article:published_time", result.Dates.Published.Source)
+		assert.True(t, time.Date(2024, 5, 12, 14, 30, 0, 0, time.UTC).Equal(result.Dates.Published.Time))
+		assert.InDelta(t, 1.0, result.Dates.Published.Confidence, 0.001)
+
+		require.NotNil(t, result.Dates.Modified)
+		assert.Equal(t, DateSourceJSONLD, result.Dates.Modified.Source)
+		assert.Equal(t, "2024-05-13T09:00:00Z", result.Dates.Modified.Raw)
+		assert.InDelta(t, 1.0, result.Dates.Modified.Confidence, 0.001)
+	})
+
+	t.Run("JSON-LD is still returned when asked for", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Dates: true, JSONLD: true})
+		require.NotNil(t, result)
+		assert.Len(t, result.JSONLD, 1)
+	})
+}
+
+// TestPickDates tests choosing between conflicting, missing and implausible dates
+func TestPickDates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		mockHTML   string
+		options    ExtractOptions
+		published  string // Raw value of the expected publication date, empty for none
+		confidence float64
+		modified   string
+	}{
+		{
+			name:       "only the url",
+			mockHTML:   `<head><title>Story</title></head>`,
+			options:    ExtractOptions{URL: "https://example.com/news/2024/05/12/story"},
+			published:  "2024-05-12",
+			confidence: dateConfidenceURL,
+		},
+		{
+			name:       "nothing",
+			mockHTML:   `<head><title>Story</title></head>`,
+			options:    ExtractOptions{URL: "https://example.com/about"},
+			confidence: 0,
+		},
+		{
+			name:       "a plain time in the body",
+			mockHTML:   `<head></head><body><p>Posted <time datetime="2024-05-12">May 12</time></p></body>`,
+			published:  "2024-05-12",
+			confidence: dateConfidenceTime,
+		},
+		{
+			name:       "itemprop meta and time",
+			mockHTML:   `<head><meta itemprop="datePublished" content="2024-05-12"></head><body><time itemprop="dateModified" datetime="2024-06-01">June</time></body>`,
+			published:  "2024-05-12",
+			confidence: 0.8,
+			modified:   "2024-06-01",
+		},
+		{
+			name: "a trusted source disagrees",
+			mockHTML: `<head><meta property="article:published_time" content="2024-05-12T10:00:00Z">
+				<meta name="parsely-pub-date" content="2024-03-01T10:00:00Z"></head>`,
+			published:  "2024-05-12T10:00:00Z",
+			confidence: 0.7,
+		},
+		{
+			name: "the more precise date wins a tie",
+			mockHTML: `<head><meta name="dcterms.date" content="2024-05-12">
+				<meta name="dc.date" content="2024-05-12T10:00:00Z"></head>`,
+			published:  "2024-05-12T10:00:00Z",
+			confidence: 0.8,
+		},
+		{
+			name: "modified before published is ignored",
+			mockHTML: `<head><meta property="article:published_time" content="2024-05-12T10:00:00Z">
+				<meta property="article:modified_time" content="2020-01-01T10:00:00Z"></head>`,
+			published:  "2024-05-12T10:00:00Z",
+			confidence: 0.9,
+		},
+		{
+			name: "implausible dates are ignored",
+			mockHTML: `<head><meta property="article:published_time" content="2999-01-01">
+				<meta name="sailthru.date" content="1970-01-01"><meta name="dc.date" content="2024-05-12"></head>`,
+			published:  "2024-05-12",
+			confidence: 0.7,
+		},
+		{
+			name:       "json-ld of a supporting node",
+			mockHTML:   `<head><script type="application/ld+json">{"@type":"Organization","dateCreated":"2001-01-01","name":"Example"}</script></head>`,
+			published:  "2001-01-01",
+			confidence: dateConfidenceJSONLDOther,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.Dates = true
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), test.options)
+			require.NotNil(t, result)
+			require.NotNil(t, result.Dates)
+			assert.NotNil(t, result.Dates.Candidates)
+
+			if len(test.published) == 0 {
+				assert.Nil(t, result.Dates.Published)
+			} else if assert.NotNil(t, result.Dates.Published) {
+				assert.Equal(t, test.published, result.Dates.Published.Raw)
+				assert.InDelta(t, test.confidence, result.Dates.Published.Confidence, 0.001)
+			}
+			if len(test.modified) == 0 {
+				assert.Nil(t, result.Dates.Modified)
+			} else if assert.NotNil(t, result.Dates.Modified) {
+				assert.Equal(t, test.modified, result.Dates.Modified.Raw)
+			}
+		})
+	}
+}
+
+// TestExtractWithOptionsDatesClock tests that dates are only in the future of the extraction's clock
+func TestExtractWithOptionsDatesClock(t *testing.T) {
+	t.Parallel()
+
+	page := `<head><meta property="article:published_time" content="2024-05-13T10:00:00Z">
+		<meta property="article:modified_time" content="2024-05-20T10:00:00Z"></head>`
+
+	// The fake clock is at 2024-05-12 10:00 UTC, one day before the publication date
+	result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Clock: newFakeClock(), Dates: true})
+	require.NotNil(t, result.Dates)
+	require.NotNil(t, result.Dates.Published)
+	assert.Equal(t, "2024-05-13T10:00:00Z", result.Dates.Published.Raw)
+	assert.Nil(t, result.Dates.Modified)
+
+	result = ExtractWithOptions(strings.NewReader(page), ExtractOptions{Dates: true})
+	require.NotNil(t, result.Dates)
+	require.NotNil(t, result.Dates.Modified)
+	assert.Equal(t, "2024-05-20T10:00:00Z", result.Dates.Modified.Raw)
+}
+
+// TestExtractWithOptionsDatesBody tests how far the body is read for <time> elements
+func TestExtractWithOptionsDatesBody(t *testing.T) {
+	t.Parallel()
+
+	page := `<head><meta property="og:title" content="Story"></head><body>
+		<time datetime="2024-05-12T10:00:00Z" pubdate>May 12</time>
+		<p>` + strings.Repeat("Text of the story. ", 50) + `</p>
+		<time datetime="2024-05-20">Comment date</time>
+		<meta property="article:published_time" content="2024-05-01T10:00:00Z">
+	</body>`
+
+	t.Run("stops at the marked time", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Dates: true})
+		require.NotNil(t, result.Dates)
+		assert.Len(t, result.Dates.Candidates, 1)
+		assert.Equal(t, DateSourceTime, result.Dates.Published.Source)
+	})
+
+	t.Run("scan limit", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head></head><body><p>`+strings.Repeat("x", 1000)+`</p><time datetime="2024-05-12">x</time></body>`),
+			ExtractOptions{Dates: true, ScanLimit: 100})
+		require.NotNil(t, result.Dates)
+		assert.Empty(t, result.Dates.Candidates)
+	})
+
+	t.Run("scan body finds late meta tags", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Dates: true, ScanBody: true})
+		require.NotNil(t, result.Dates)
+		assert.Len(t, result.Dates.Candidates, 3)
+		assert.Equal(t, "article:published_time", result.Dates.Published.Source)
+	})
+
+	t.Run("json-ld in the body", func(t *testing.T) {
+		page := `<head><title>Story</title></head><body><p>Text of the story.</p>
+			<script type="application/ld+json">{"@type":"NewsArticle","datePublished":"2024-05-12T10:00:00Z","author":{"@type":"Person","name":"Jane Doe"},"keywords":"go, html"}</script>
+			<script type="application/ld+json">{"@type":"Product","name":"Widget","brand":"Acme","offers":{"price":"12.50","priceCurrency":"USD"}}</script></body>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Authors: true, Dates: true, Keywords: true, Product: true})
+		require.NotNil(t, result.Dates)
+		require.NotNil(t, result.Dates.Published)
+		assert.Equal(t, DateSourceJSONLD, result.Dates.Published.Source)
+		assert.Equal(t, "2024-05-12T10:00:00Z", result.Dates.Published.Raw)
+		require.Len(t, result.Authors, 1)
+		assert.Equal(t, "Jane Doe", result.Authors[0].Name)
+		assert.Equal(t, []string{"go", "html"}, result.Keywords)
+		require.NotNil(t, result.Product)
+		assert.Equal(t, "Acme", result.Product.Brand)
+		assert.Equal(t, "12.50", result.Product.Price)
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta name="sailthru.date" content="soon"></head>`), ExtractOptions{Dates: true, Warnings: true})
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningInvalidDate, result.Warnings[0].Code)
+		assert.Equal(t, "sailthru.date", result.Warnings[0].Tag)
+	})
+}
@@ -4,12 +4,23 @@ package metaextractor
 type Tags struct {
 	Author              string `json:"author"`
 	Description         string `json:"description"`
+	Language            string `json:"language"` // Declared language in BCP 47 form, from <html lang>, Content-Language or og:locale
 	OGAuthor            string `json:"og_author"`
 	OGDescription       string `json:"og_description"`
 	OGImage             string `json:"og_image"`
+	OGImageHeight       string `json:"og_image_height"`
+	OGImageWidth        string `json:"og_image_width"`
+	OGLocale            string `json:"og_locale"` // In BCP 47 form (e.g. "en-US" for "en_US")
 	OGPublisher         string `json:"og_publisher"`
 	OGSiteName          string `json:"og_site_name"`
 	OGTitle             string `json:"og_title"`
+	OGType              string `json:"og_type"`
+	OGURL               string `json:"og_url"`
+	OGVideo             string `json:"og_video"`
+	OGVideoHeight       string `json:"og_video_height"`
+	OGVideoType         string `json:"og_video_type"`
+	OGVideoWidth        string `json:"og_video_width"`
+	ThemeColor          string `json:"theme_color"`
 	Title               string `json:"title"`
 	TwitterDescription  string `json:"twitter_description"`
 	TwitterImage        string `json:"twitter_image"`
@@ -20,33 +31,272 @@ type Tags struct {
 	TwitterTitle        string `json:"twitter_title"`
 }
 
+// ExtractOptions are the options used by ExtractWithOptions
+type ExtractOptions struct {
+	Alternates     bool   // Collect the hreflang alternates of the document
+	AppLinks       bool   // Collect the App Links and Facebook app tags (see Result.AppLinks)
+	Authors        bool   // Collect the authors from the meta tags, JSON-LD and byline markup (see Result.Authors)
+	CleanTitle     bool   // Remove the site name from the title (see CleanTitle), keeping the original in OriginalTitle
+	Clock          Clock  // Source of the current time, used to ignore future dates (defaults to the system clock)
+	Dates          bool   // Find the publication and modification dates (see Result.Dates)
+	DetectLanguage bool   // Detect the language of the title and description, even when one is declared (see DetectLanguage)
+	Fallbacks      bool   // Derive a missing description and image from the body content (listed in Result.Heuristic)
+	JSONLD         bool   // Collect the raw JSON-LD script blocks
+	HTTPEquiv      bool   // Collect the <meta http-equiv> values such as refresh and Content-Type (see Result.HTTPEquiv)
+	Keywords       bool   // Collect the keywords from the meta tags and JSON-LD (see Result.Keywords)
+	Links          bool   // Collect the <link> tags (icons, canonical, alternates, etc.)
+	Normalize      bool   // Clean up the whitespace and invisible characters of every field (see NormalizeText)
+	Platforms      bool   // Collect the Pinterest, LinkedIn, VK and Slack tags (see Result.Platforms)
+	Product        bool   // Collect the price, availability and brand of product pages (see Result.Product)
+	Provenance     bool   // Record which tag supplied each field
+	ScanBody       bool   // Keep reading after <body> to find metadata emitted late (slower)
+	ScanLimit      int    // Maximum number of bytes read after <body> with ScanBody, Authors, Dates, Fallbacks, Keywords or Product (zero reads everything)
+	StripTags      bool   // Remove HTML tags left in the values of every field (see StripTags)
+	URL            string // Address of the document, used when cleaning the title and resolving fallback images
+	Warnings       bool   // Report problems found in the document's metadata
+}
+
+// Result is the outcome of ExtractWithOptions, the tags plus any extra details
+// about the extraction that were enabled in the ExtractOptions
+type Result struct {
+	Tags
+
+	Alternates       []Alternate           `json:"alternates,omitempty"`
+	AppLinks         *AppLinks             `json:"app_links,omitempty"`
+	Authors          []Author              `json:"authors,omitempty"` // De-duplicated, the JSON-LD ones first
+	Dates            *Dates                `json:"dates,omitempty"`
+	DetectedLanguage string                `json:"detected_language,omitempty"` // Language detected by the DetectLanguage option, compare it with Language to flag mismatches
+	Heuristic        []string              `json:"heuristic,omitempty"`         // Fields derived from the body content by the Fallbacks option
+	HTTPEquiv        *HTTPEquiv            `json:"http_equiv,omitempty"`
+	JSONLD           []string              `json:"json_ld,omitempty"`         // Raw <script type="application/ld+json"> blocks
+	KeywordSources   map[string][]string   `json:"keyword_sources,omitempty"` // Keyed by keyword, the tags it was found in
+	Keywords         []string              `json:"keywords,omitempty"`        // De-duplicated ignoring case, in the order found
+	Links            []Link                `json:"links,omitempty"`
+	OGDeclared       []string              `json:"og_declared,omitempty"` // Open Graph properties declared with a value, fields can also be filled in from other tags (see Validate)
+	Platforms        *PlatformTags         `json:"platforms,omitempty"`
+	Product          *Product              `json:"product,omitempty"`
+	OriginalTitle    string                `json:"original_title,omitempty"` // Title before it was cleaned (only set if it changed)
+	Provenance       map[string]Provenance `json:"provenance,omitempty"`     // Keyed by field name (see the Field constants)
+	Warnings         []Warning             `json:"warnings,omitempty"`
+}
+
+// Alternate is a version of the document in another language, from a
+// <link rel="alternate" hreflang="..."> tag
+type Alternate struct {
+	Href     string `json:"href"`
+	Language string `json:"language"` // In BCP 47 form, or "x-default" for the version shown to any other language
+}
+
+// Link is a <link> tag found in the <head>
+type Link struct {
+	Href     string `json:"href"`
+	Hreflang string `json:"hreflang,omitempty"`
+	Rel      string `json:"rel"` // Lowercase, may hold several space separated values (e.g. "shortcut icon")
+	Sizes    string `json:"sizes,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+// Provenance records where the value of a field came from
+type Provenance struct {
+	Cleaned   bool   `json:"cleaned,omitempty"` // True if the value was changed after it was read (e.g. by the CleanTitle option)
+	Line      int    `json:"line"`              // Line of the source tag (starting at 1)
+	Offset    int    `json:"offset"`            // Byte offset of the source tag in the document
+	Source    string `json:"source"`            // Tag that supplied the value (e.g. "title", "og:title" or "twitter:title")
+	Truncated bool   `json:"truncated"`         // True if the value was cut at MaxFieldLength
+}
+
+// WarningCode identifies the kind of problem reported by a Warning
+type WarningCode string
+
+// Warning codes
+const (
+	WarningDuplicateTag    WarningCode = "duplicate_tag"    // A tag was repeated with a different value, the last one wins (the first one for <title>)
+	WarningEmptyContent    WarningCode = "empty_content"    // A known meta tag has an empty content attribute
+	WarningInvalidDate     WarningCode = "invalid_date"     // A date meta tag could not be parsed (see ParseDate)
+	WarningInvalidLanguage WarningCode = "invalid_language" // A declared language is not a valid BCP 47 tag
+	WarningInvalidProduct  WarningCode = "invalid_product"  // A product price, currency or availability could not be parsed
+	WarningInvalidRefresh  WarningCode = "invalid_refresh"  // A <meta http-equiv="refresh"> could not be parsed (see ParseRefresh)
+	WarningMissingContent  WarningCode = "missing_content"  // A known meta tag has no content attribute at all
+	WarningTitleInBody     WarningCode = "title_in_body"    // A <title> was found outside of the <head>
+	WarningTruncated       WarningCode = "truncated"        // A value was cut at MaxFieldLength
+)
+
+// Warning is a problem found in the metadata of a document
+type Warning struct {
+	Code    WarningCode `json:"code"`
+	Field   string      `json:"field,omitempty"` // Field affected by the problem (see the Field constants)
+	Line    int         `json:"line"`            // Line of the tag (starting at 1)
+	Message string      `json:"message"`
+	Offset  int         `json:"offset"` // Byte offset of the tag in the document
+	Tag     string      `json:"tag,omitempty"`
+}
+
 // todo: parse the apple mobile title
 // <meta name="apple-mobile-web-app-title" content="SiteTitle"/>
 
 // MaxFieldLength defines the maximum length for any extracted field to prevent memory issues
 const MaxFieldLength = 10000
 
+// MaxJSONLDLength is the maximum length of a JSON-LD block, longer blocks are skipped
+// since truncating them would leave invalid JSON
+const MaxJSONLDLength = 256 << 10
+
+// Foreign elements, whose <title> describes the element rather than the document
+const (
+	tagMath = "math"
+	tagSVG  = "svg"
+)
+
 // Tag and Property constants for parsing
 const (
-	TagBody                = "body"
-	TagContent             = "content"
-	TagMeta                = "meta"
-	TagMetaAuthor          = "author"
-	TagMetaDescription     = "description"
-	TagName                = "name"
-	TagOGAuthor            = "og:author"
-	TagOGDescription       = "og:description"
-	TagOGImage             = "og:image"
-	TagOGPublisher         = "og:publisher"
-	TagOGSiteName          = "og:site_name"
-	TagOGTitle             = "og:title"
-	TagProperty            = "property"
-	TagTitle               = "title"
-	TagTwitterCard         = "twitter:card"
-	TagTwitterDescription  = "twitter:description"
-	TagTwitterImage        = "twitter:image"
-	TagTwitterPlayer       = "twitter:player"
-	TagTwitterPlayerHeight = "twitter:player:height"
-	TagTwitterPlayerWidth  = "twitter:player:width"
-	TagTwitterTitle        = "twitter:title"
+	TagArticleAuthor         = "article:author"
+	TagArticleTag            = "article:tag"
+	TagBody                  = "body"
+	TagCharset               = "charset"
+	TagContent               = "content"
+	TagContentLanguage       = "content-language"
+	TagContentSecurityPolicy = "content-security-policy"
+	TagContentType           = "content-type"
+	TagDefaultStyle          = "default-style"
+	TagFBAppID               = "fb:app_id"
+	TagFBPages               = "fb:pages"
+	TagHead                  = "head"
+	TagDatetime              = "datetime"
+	TagHTML                  = "html"
+	TagHTTPEquiv             = "http-equiv"
+	TagHref                  = "href"
+	TagHreflang              = "hreflang"
+	TagImg                   = "img"
+	TagItemprop              = "itemprop"
+	TagKeywords              = "keywords"
+	TagLang                  = "lang"
+	TagLink                  = "link"
+	TagLinkedInImage         = "image"
+	TagMeta                  = "meta"
+	TagMetaAuthor            = "author"
+	TagMetaDescription       = "description"
+	TagName                  = "name"
+	TagNewsKeywords          = "news_keywords"
+	TagNoPin                 = "nopin"
+	TagOGAuthor              = "og:author"
+	TagOGAvailability        = "og:availability"
+	TagOGBrand               = "og:brand"
+	TagOGDescription         = "og:description"
+	TagOGImage               = "og:image"
+	TagOGImageHeight         = "og:image:height"
+	TagOGImageWidth          = "og:image:width"
+	TagOGLocale              = "og:locale"
+	TagOGPriceAmount         = "og:price:amount"
+	TagOGPriceCurrency       = "og:price:currency"
+	TagOGPublisher           = "og:publisher"
+	TagOGSiteName            = "og:site_name"
+	TagOGTitle               = "og:title"
+	TagOGType                = "og:type"
+	TagOGURL                 = "og:url"
+	TagOGVideo               = "og:video"
+	TagOGVideoHeight         = "og:video:height"
+	TagOGVideoSecureURL      = "og:video:secure_url"
+	TagOGVideoType           = "og:video:type"
+	TagOGVideoURL            = "og:video:url"
+	TagOGVideoWidth          = "og:video:width"
+	TagParagraph             = "p"
+	TagParselyTags           = "parsely-tags"
+	TagPinterest             = "pinterest"
+	TagPinterestDescription  = "pinterest:description"
+	TagPinterestRichPin      = "pinterest-rich-pin"
+	TagProductAvailability   = "product:availability"
+	TagProductBrand          = "product:brand"
+	TagProductPriceAmount    = "product:price:amount"
+	TagProductPriceCurrency  = "product:price:currency"
+	TagProductRetailerItemID = "product:retailer_item_id"
+	TagProperty              = "property"
+	TagPubdate               = "pubdate"
+	TagRefresh               = "refresh"
+	TagRel                   = "rel"
+	TagScript                = "script"
+	TagSizes                 = "sizes"
+	TagThemeColor            = "theme-color"
+	TagTime                  = "time"
+	TagTitle                 = "title"
+	TagTwitterCard           = "twitter:card"
+	TagTwitterCreator        = "twitter:creator"
+	TagTwitterData           = "twitter:data"
+	TagTwitterDescription    = "twitter:description"
+	TagTwitterImage          = "twitter:image"
+	TagTwitterLabel          = "twitter:label"
+	TagTwitterPlayer         = "twitter:player"
+	TagTwitterPlayerHeight   = "twitter:player:height"
+	TagTwitterPlayerWidth    = "twitter:player:width"
+	TagTwitterTitle          = "twitter:title"
+	TagType                  = "type"
+	TagVKImage               = "vk:image"
+	TagXMLLang               = "xml:lang"
+)
+
+// MIME types
+const (
+	TypeJSONLD = "application/ld+json"
 )
+
+// Field names of the Tags, matching their JSON keys
+const (
+	FieldAuthor              = "author"
+	FieldDescription         = "description"
+	FieldLanguage            = "language"
+	FieldOGAuthor            = "og_author"
+	FieldOGDescription       = "og_description"
+	FieldOGImage             = "og_image"
+	FieldOGImageHeight       = "og_image_height"
+	FieldOGImageWidth        = "og_image_width"
+	FieldOGLocale            = "og_locale"
+	FieldOGPublisher         = "og_publisher"
+	FieldOGSiteName          = "og_site_name"
+	FieldOGTitle             = "og_title"
+	FieldOGType              = "og_type"
+	FieldOGURL               = "og_url"
+	FieldOGVideo             = "og_video"
+	FieldOGVideoHeight       = "og_video_height"
+	FieldOGVideoType         = "og_video_type"
+	FieldOGVideoWidth        = "og_video_width"
+	FieldThemeColor          = "theme_color"
+	FieldTitle               = "title"
+	FieldTwitterCard         = "twitter_card"
+	FieldTwitterDescription  = "twitter_description"
+	FieldTwitterImage        = "twitter_image"
+	FieldTwitterPlayer       = "twitter_player"
+	FieldTwitterPlayerHeight = "twitter_player_height"
+	FieldTwitterPlayerWidth  = "twitter_player_width"
+	FieldTwitterTitle        = "twitter_title"
+)
+
+// knownMetaTags maps the meta names and properties that are extracted to their field
+//
+// theme-color is left out since pages may have one per media query
+var knownMetaTags = map[string]string{
+	TagMetaAuthor:          FieldAuthor,
+	TagMetaDescription:     FieldDescription,
+	TagOGAuthor:            FieldOGAuthor,
+	TagOGDescription:       FieldOGDescription,
+	TagOGImage:             FieldOGImage,
+	TagOGImageHeight:       FieldOGImageHeight,
+	TagOGImageWidth:        FieldOGImageWidth,
+	TagOGLocale:            FieldOGLocale,
+	TagOGPublisher:         FieldOGPublisher,
+	TagOGSiteName:          FieldOGSiteName,
+	TagOGTitle:             FieldOGTitle,
+	TagOGType:              FieldOGType,
+	TagOGURL:               FieldOGURL,
+	TagOGVideo:             FieldOGVideo,
+	TagOGVideoHeight:       FieldOGVideoHeight,
+	TagOGVideoSecureURL:    FieldOGVideo,
+	TagOGVideoType:         FieldOGVideoType,
+	TagOGVideoURL:          FieldOGVideo,
+	TagOGVideoWidth:        FieldOGVideoWidth,
+	TagTwitterCard:         FieldTwitterCard,
+	TagTwitterDescription:  FieldTwitterDescription,
+	TagTwitterImage:        FieldTwitterImage,
+	TagTwitterPlayer:       FieldTwitterPlayer,
+	TagTwitterPlayerHeight: FieldTwitterPlayerHeight,
+	TagTwitterPlayerWidth:  FieldTwitterPlayerWidth,
+	TagTwitterTitle:        FieldTwitterTitle,
+}
@@ -7,126 +7,610 @@
 package metaextractor
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 // Extract is the method used to extract HTML tags
 func Extract(resp io.Reader) (tags Tags) {
+	return ExtractWithOptions(resp, ExtractOptions{}).Tags
+}
+
+// ExtractWithOptions is the method used to extract HTML tags along with any
+// extra details about the extraction that are enabled in options
+func ExtractWithOptions(resp io.Reader, options ExtractOptions) *Result {
+	e := &extractor{
+		options: options,
+		result:  &Result{},
+	}
+	e.cursor = cursor{
+		bodyOffset: -1,
+		lines:      options.Provenance || options.Warnings,
+		nextLine:   1,
+	}
+	e.cursor.offsets = e.cursor.lines || (options.ScanLimit > 0 && (options.ScanBody || e.readsBody()))
+	if options.Provenance {
+		e.result.Provenance = make(map[string]Provenance)
+	}
+	if options.Warnings {
+		e.seen = make(map[string]string)
+	}
+	if options.Dates {
+		clock := options.Clock
+		if clock == nil {
+			clock = systemClock{}
+		}
+		e.now = clock.Now()
+	}
+	e.run(resp)
+	if options.JSONLD {
+		e.result.JSONLD = e.jsonLD
+	}
+	if options.Authors {
+		e.pickAuthors()
+	}
+	if options.Dates {
+		e.pickDates()
+	}
+	if options.Keywords {
+		e.pickKeywords()
+	}
+	if options.Platforms {
+		e.pickPlatforms()
+	}
+	if options.Product {
+		e.pickProduct()
+	}
+	if options.Fallbacks {
+		e.applyFallbacks()
+	}
+	if options.CleanTitle {
+		e.cleanTitle()
+	}
+	if options.DetectLanguage {
+		e.result.DetectedLanguage = DetectLanguage(e.result.Title + "\n" + e.result.Description)
+	}
+	return e.result
+}
+
+// extractor holds the state of a single extraction
+type extractor struct {
+	attrs        []html.Attribute // Attributes of the current start tag, reused between tags
+	author       authorState
+	cursor       cursor
+	dates        []Date // Date candidates found so far
+	datesFound   bool   // Found a <time> marked as the publication date, the body is not read further for dates
+	fallback     fallbackState
+	foreignDepth int       // Depth of nested <svg> and <math> elements, whose <title> is not the document's
+	headClosed   bool      // Seen the </head> end tag
+	inJSONLD     bool      // Inside a JSON-LD <script>
+	inTitle      bool      // Inside the document's <title>
+	jsonLD       []string  // JSON-LD blocks, collected for the JSONLD, Authors, Dates, Keywords and Product options
+	keywords     []keyword // Keywords found so far
+	line         int       // Line of the current token (starting at 1)
+	now          time.Time // Time of the extraction, later dates are ignored
+	offset       int       // Byte offset of the current token
+	options      ExtractOptions
+	platform     platformState
+	product      map[string]productMeta // Values of the product meta tags, by name or property
+	result       *Result
+	seen         map[string]string // First value of each known tag, used to detect duplicates
+	title        titleState
+}
+
+// cursor tracks the position of the tokens in the document
+type cursor struct {
+	bodyOffset int  // Byte offset of the <body> start tag (-1 until it is found)
+	lines      bool // Count lines, only needed for provenance and warnings
+	nextLine   int
+	nextOffset int
+	offsets    bool // Count byte offsets
+}
+
+// titleState holds the text of the <title> being read and the first document title
+type titleState struct {
+	first  string // Text of the first non-blank document title
+	found  bool   // The first document title was found
+	line   int    // Line of the text of the current title
+	offset int    // Byte offset of the text of the current title
+	text   []byte // Text of the current title, which may span several tokens
+}
+
+// run tokenizes the document and extracts the tags until the <body> is reached,
+// or until the end of the document (or the scan limit) in ScanBody mode
+func (e *extractor) run(resp io.Reader) {
 	// Tokenize the response
 	z := html.NewTokenizer(resp)
 
-	// Set the values
-	var value string
-	var ok bool
-	titleFound := false
-
 	// Loop elements
 	for {
-		tt := z.Next()
+		tt := e.next(z)
+
 		switch tt {
 		case html.ErrorToken:
-			return tags
+			if e.inTitle {
+				e.endTitle()
+			}
+			return
 		case html.StartTagToken, html.SelfClosingTagToken:
-			t := z.Token()
-			if t.Data == TagBody {
-				return tags
+			// Most tags are not read, so skip them before copying their attributes
+			name, hasAttr := z.TagName()
+			if !e.readsTag(name) {
+				continue
 			}
-			if t.Data == TagTitle {
-				titleFound = true
+			if string(name) == TagMeta && !e.readsMetaToken() {
+				e.readMeta(z, hasAttr)
+				continue
 			}
-			if t.Data == TagMeta {
 
-				if value, ok = extractMetaProperty(t, TagMetaDescription); ok {
-					tags.Description = truncateField(value, MaxFieldLength)
-				}
-
-				if value, ok = extractMetaProperty(t, TagMetaAuthor); ok {
-					tags.Author = truncateField(value, MaxFieldLength)
-				}
-
-				if value, ok = extractMetaProperty(t, TagOGTitle); ok {
-					tags.OGTitle = truncateField(value, MaxFieldLength)
-					if len(tags.Title) == 0 {
-						tags.Title = truncateField(value, MaxFieldLength)
+			t := e.token(z, tt, name, hasAttr)
+			if t.Data == TagBody && e.cursor.bodyOffset < 0 {
+				e.cursor.bodyOffset = e.offset
+				e.headClosed = true
+				if !e.options.ScanBody {
+					if e.readsBody() {
+						e.scanBody(z)
 					}
+					return
 				}
-
-				if value, ok = extractMetaProperty(t, TagOGDescription); ok {
-					tags.OGDescription = truncateField(value, MaxFieldLength)
-					if len(tags.Description) == 0 {
-						tags.Description = truncateField(value, MaxFieldLength)
-					}
+			}
+			if e.options.Fallbacks && e.cursor.bodyOffset >= 0 {
+				e.fallbackStartTag(t, tt)
+			}
+			if (t.Data == tagSVG || t.Data == tagMath) && tt == html.StartTagToken {
+				e.foreignDepth++
+			}
+			if t.Data == TagHTML {
+				e.htmlLanguage(t)
+			}
+			if t.Data == TagLink && (e.options.Links || e.options.Alternates) {
+				e.addLink(t)
+			}
+			if e.options.Authors {
+				e.authorStartTag(t, tt)
+			}
+			if t.Data == TagScript && e.readsJSONLD() {
+				e.inJSONLD = isJSONLDScript(t)
+			}
+			if t.Data == TagTime && e.options.Dates {
+				e.addTimeDate(t)
+			}
+			if t.Data == TagTitle && e.foreignDepth == 0 && tt == html.StartTagToken {
+				e.inTitle = true
+				e.title.text = e.title.text[:0]
+				if e.options.Warnings && e.headClosed {
+					e.warn(WarningTitleInBody, FieldTitle, TagTitle, "<title> found outside of the <head>")
 				}
-
-				if value, ok = extractMetaProperty(t, TagOGImage); ok {
-					tags.OGImage = truncateField(value, MaxFieldLength)
+			}
+			if t.Data == TagMeta {
+				e.addMeta(t)
+			}
+		case html.TextToken:
+			switch {
+			case e.inJSONLD:
+				e.addJSONLD(z.Text())
+			case e.inTitle:
+				if len(e.title.text) == 0 {
+					e.title.line, e.title.offset = e.line, e.offset
 				}
-
-				if value, ok = extractMetaProperty(t, TagOGSiteName); ok {
-					tags.OGSiteName = truncateField(value, MaxFieldLength)
+				e.title.text = append(e.title.text, z.Text()...)
+			case e.options.Fallbacks && e.cursor.bodyOffset >= 0, e.author.capture != nil:
+				e.bodyText(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if e.options.Fallbacks && e.cursor.bodyOffset >= 0 {
+				e.fallbackEndTag(string(name))
+			}
+			if e.options.Authors {
+				e.authorEndTag(string(name))
+			}
+			switch string(name) {
+			case TagHead:
+				e.headClosed = true
+			case TagScript:
+				e.inJSONLD = false
+			case TagTitle:
+				if e.inTitle {
+					e.endTitle()
 				}
+			case tagSVG, tagMath:
+				e.foreignDepth = max(e.foreignDepth-1, 0)
+			}
+		case html.CommentToken, html.DoctypeToken:
+			continue
+		}
+	}
+}
 
-				if value, ok = extractMetaProperty(t, TagOGPublisher); ok {
-					tags.OGPublisher = truncateField(value, MaxFieldLength)
-				}
+// readsBody returns true if an option needs to read the body even without ScanBody
+func (e *extractor) readsBody() bool {
+	return e.options.Authors || e.options.Dates || e.options.Fallbacks || e.options.Keywords || e.options.Product
+}
 
-				if value, ok = extractMetaProperty(t, TagOGAuthor); ok {
-					tags.OGAuthor = truncateField(value, MaxFieldLength)
-					if len(tags.Author) == 0 {
-						tags.Author = truncateField(value, MaxFieldLength)
-					}
-				}
+// readsJSONLD returns true if an option needs the JSON-LD blocks
+func (e *extractor) readsJSONLD() bool {
+	return e.options.JSONLD || e.options.Authors || e.options.Dates || e.options.Keywords || e.options.Product
+}
 
-				// Twitter card (use if OG not found)
-				if value, ok = extractMetaProperty(t, TagTwitterTitle); ok {
-					tags.TwitterTitle = truncateField(value, MaxFieldLength)
-					if len(tags.Title) == 0 {
-						tags.Title = truncateField(value, MaxFieldLength)
-					}
-				}
+// readsAllTags returns true if an option needs every start tag, such as the
+// bylines of the Authors option or the body text of the Fallbacks option
+func (e *extractor) readsAllTags() bool {
+	return e.options.Authors || (e.options.Fallbacks && e.cursor.bodyOffset >= 0)
+}
 
-				if value, ok = extractMetaProperty(t, TagTwitterDescription); ok {
-					tags.TwitterDescription = truncateField(value, MaxFieldLength)
-					if len(tags.Description) == 0 {
-						tags.Description = truncateField(value, MaxFieldLength)
-					}
-				}
+// readsTag returns true if a start tag is needed by the extraction or its options
+func (e *extractor) readsTag(name []byte) bool {
+	if e.readsAllTags() {
+		return true
+	}
+	switch string(name) {
+	case TagBody, TagHTML, TagMeta, TagTitle, tagMath, tagSVG:
+		return true
+	case TagLink:
+		return e.options.Links || e.options.Alternates
+	case TagScript:
+		return e.readsJSONLD()
+	case TagTime:
+		return e.options.Dates
+	}
+	return false
+}
 
-				if value, ok = extractMetaProperty(t, TagTwitterImage); ok {
-					tags.TwitterImage = truncateField(value, MaxFieldLength)
-					if len(tags.OGImage) == 0 {
-						tags.OGImage = truncateField(value, MaxFieldLength)
-					}
-				}
+// readsMetaToken returns true if an option needs the whole <meta> token,
+// otherwise the meta tags are read straight from the tokenizer
+func (e *extractor) readsMetaToken() bool {
+	return e.readsAllTags() || e.options.Warnings || e.options.AppLinks || e.options.Dates ||
+		e.options.HTTPEquiv || e.options.Keywords || e.options.Platforms || e.options.Product
+}
 
-				if value, ok = extractMetaProperty(t, TagTwitterCard); ok {
-					tags.TwitterCard = truncateField(value, MaxFieldLength)
-				}
+// token returns the current start tag like z.Token, reusing the attributes of
+// the previous tag and interning the names known to the atom package
+func (e *extractor) token(z *html.Tokenizer, tt html.TokenType, name []byte, hasAttr bool) html.Token {
+	t := html.Token{Type: tt, DataAtom: atom.Lookup(name)}
+	if t.DataAtom != 0 {
+		t.Data = t.DataAtom.String()
+	} else {
+		t.Data = string(name)
+	}
+	e.attrs = e.attrs[:0]
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		e.attrs = append(e.attrs, html.Attribute{Key: atom.String(key), Val: string(val)})
+	}
+	t.Attr = e.attrs
+	return t
+}
 
-				if value, ok = extractMetaProperty(t, TagTwitterPlayer); ok {
-					tags.TwitterPlayer = truncateField(value, MaxFieldLength)
-				}
-				if value, ok = extractMetaProperty(t, TagTwitterPlayerWidth); ok {
-					tags.TwitterPlayerWidth = truncateField(value, MaxFieldLength)
-				}
-				if value, ok = extractMetaProperty(t, TagTwitterPlayerHeight); ok {
-					tags.TwitterPlayerHeight = truncateField(value, MaxFieldLength)
-				}
+// addMeta passes a <meta> token to the options reading it, then sets the Tags
+func (e *extractor) addMeta(t html.Token) {
+	if e.options.Warnings {
+		e.checkMeta(t)
+	}
+	if e.options.AppLinks {
+		e.addMetaAppLink(t)
+	}
+	if e.options.Authors {
+		e.addMetaAuthor(t)
+	}
+	if e.options.Dates {
+		e.addMetaDate(t)
+	}
+	if e.options.HTTPEquiv {
+		e.addHTTPEquiv(t)
+	}
+	if e.options.Keywords {
+		e.addMetaKeywords(t)
+	}
+	if e.options.Platforms {
+		e.addMetaPlatform(t)
+	}
+	if e.options.Product {
+		e.addMetaProduct(t)
+	}
+	m := newMetaTag(t)
+	e.applyMeta(&m)
+}
+
+// scanBody reads the body for the Authors, Dates, Fallbacks, Keywords and
+// Product options (bylines, <time> elements, text and JSON-LD blocks), until
+// they have found what they need
+func (e *extractor) scanBody(z *html.Tokenizer) {
+	for !e.bodyDone() {
+		switch tt := e.next(z); tt {
+		case html.ErrorToken:
+			return
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !e.options.Authors && !e.options.Fallbacks && string(name) != TagTime && string(name) != TagScript {
+				continue
+			}
+			t := e.token(z, tt, name, hasAttr)
+			if e.options.Authors {
+				e.authorStartTag(t, tt)
+			}
+			if t.Data == TagScript && e.readsJSONLD() {
+				e.inJSONLD = isJSONLDScript(t)
+			}
+			if t.Data == TagTime && e.options.Dates {
+				e.addTimeDate(t)
+			}
+			if e.options.Fallbacks {
+				e.fallbackStartTag(t, tt)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if e.options.Authors {
+				e.authorEndTag(string(name))
+			}
+			if e.options.Fallbacks {
+				e.fallbackEndTag(string(name))
+			}
+			if string(name) == TagScript {
+				e.inJSONLD = false
 			}
 		case html.TextToken:
-			if titleFound {
-				t := z.Token()
-				tags.Title = truncateField(t.Data, MaxFieldLength)
-				titleFound = false
+			switch {
+			case e.inJSONLD:
+				e.addJSONLD(z.Text())
+			case e.options.Fallbacks, e.author.capture != nil:
+				e.bodyText(z.Text())
 			}
-		case html.CommentToken, html.DoctypeToken, html.EndTagToken:
+		case html.CommentToken, html.DoctypeToken:
 			continue
 		}
 	}
 }
 
+// addJSONLD collects the text of a JSON-LD <script>, ignoring blocks over MaxJSONLDLength
+func (e *extractor) addJSONLD(data []byte) {
+	if len(data) <= MaxJSONLDLength {
+		e.jsonLD = append(e.jsonLD, string(data))
+	}
+	e.inJSONLD = false
+}
+
+// bodyText passes the text of the body to the options reading it
+func (e *extractor) bodyText(text []byte) {
+	if e.options.Fallbacks && e.cursor.bodyOffset >= 0 {
+		e.fallbackText(text)
+	}
+	e.authorText(text)
+}
+
+// bodyDone returns true once the options reading the body have found what they
+// need in it, the Keywords and Product options read all of its JSON-LD blocks
+func (e *extractor) bodyDone() bool {
+	return (!e.options.Authors || e.author.found) &&
+		(!e.options.Dates || e.datesFound) &&
+		(!e.options.Fallbacks || e.fallbacksDone()) &&
+		!e.options.Keywords && !e.options.Product
+}
+
+// next reads the next token, tracking its position for provenance, warnings and the scan limit
+func (e *extractor) next(z *html.Tokenizer) html.TokenType {
+	tt := z.Next()
+	c := &e.cursor
+	if !c.offsets {
+		return tt
+	}
+
+	raw := z.Raw()
+	e.offset = c.nextOffset
+	c.nextOffset += len(raw)
+	if c.lines {
+		e.line = c.nextLine
+		c.nextLine += bytes.Count(raw, []byte{'\n'})
+	}
+	if c.bodyOffset >= 0 && e.options.ScanLimit > 0 && e.offset-c.bodyOffset > e.options.ScanLimit {
+		return html.ErrorToken
+	}
+	return tt
+}
+
+// endTitle finishes reading a <title>, the first one that is not blank becomes
+// the document title and any later ones are ignored
+func (e *extractor) endTitle() {
+	e.inTitle = false
+	text := string(e.title.text)
+	if len(strings.TrimSpace(text)) == 0 {
+		return
+	}
+
+	// Report the title at the position of its text rather than the </title>
+	line, offset := e.line, e.offset
+	e.line, e.offset = e.title.line, e.title.offset
+	defer func() {
+		e.line, e.offset = line, offset
+	}()
+
+	if e.title.found {
+		if e.options.Warnings && text != e.title.first {
+			e.warn(WarningDuplicateTag, FieldTitle, TagTitle, fmt.Sprintf("duplicate title %q is ignored, keeping %q", text, e.title.first))
+		}
+		return
+	}
+	e.title.found, e.title.first = true, text
+	e.set(&e.result.Title, FieldTitle, TagTitle, text)
+}
+
+// cleanTitle removes the site name from the title, keeping the original
+func (e *extractor) cleanTitle() {
+	host := ""
+	if u, err := url.Parse(e.options.URL); err == nil {
+		host = u.Hostname()
+	}
+	title := e.result.Title
+	if cleaned := CleanTitle(title, e.result.OGSiteName, host); cleaned != title {
+		e.result.OriginalTitle = title
+		e.result.Title = cleaned
+		if provenance, ok := e.result.Provenance[FieldTitle]; ok {
+			e.result.Provenance[FieldOriginalTitle] = provenance
+			provenance.Cleaned = true
+			e.result.Provenance[FieldTitle] = provenance
+		}
+	}
+}
+
+// set assigns a truncated value to a field, recording its provenance if enabled
+//
+// The value is cleaned up first when StripTags or Normalize are enabled
+func (e *extractor) set(dst *string, field, source, value string) {
+	if e.options.StripTags {
+		value = StripTags(value)
+	}
+	if e.options.Normalize {
+		value = NormalizeText(value)
+	}
+	*dst = truncateField(value, MaxFieldLength)
+	if e.options.Provenance {
+		e.result.Provenance[field] = Provenance{
+			Line:      e.line,
+			Offset:    e.offset,
+			Source:    source,
+			Truncated: len(*dst) < len(value),
+		}
+	}
+	if e.options.Warnings && len(*dst) < len(value) {
+		e.warn(WarningTruncated, field, source, fmt.Sprintf("value truncated from %d to %d bytes", len(value), len(*dst)))
+	}
+}
+
+// setFirst sets a field like set, unless it already has a value
+func (e *extractor) setFirst(dst *string, field, source, value string) {
+	if len(*dst) == 0 {
+		e.set(dst, field, source, value)
+	}
+}
+
+// warn adds a warning about the current token to the result
+func (e *extractor) warn(code WarningCode, field, tag, message string) {
+	e.result.Warnings = append(e.result.Warnings, Warning{
+		Code:    code,
+		Field:   field,
+		Line:    e.line,
+		Message: message,
+		Offset:  e.offset,
+		Tag:     tag,
+	})
+}
+
+// checkMeta warns about known meta tags that have an empty or missing content attribute,
+// or that repeat an earlier tag with a different value
+func (e *extractor) checkMeta(t html.Token) {
+	key, content, hasContent := metaAttributes(t)
+	field, known := knownMetaTags[key]
+	if !known {
+		return
+	}
+
+	switch {
+	case !hasContent:
+		e.warn(WarningMissingContent, field, key, "meta tag has no content attribute")
+	case len(strings.TrimSpace(content)) == 0:
+		e.warn(WarningEmptyContent, field, key, "meta tag has an empty content attribute")
+	default:
+		e.checkDuplicate(key, field, content)
+	}
+}
+
+// checkDuplicate warns when a tag is seen again with a different value
+func (e *extractor) checkDuplicate(tag, field, value string) {
+	first, ok := e.seen[tag]
+	if !ok {
+		e.seen[tag] = value
+		return
+	}
+	if first != value {
+		e.warn(WarningDuplicateTag, field, tag, fmt.Sprintf("duplicate %s overrides %q with %q", tag, first, value))
+	}
+}
+
+// htmlLanguage sets the declared language from the lang (or xml:lang) attribute of <html>
+func (e *extractor) htmlLanguage(t html.Token) {
+	lang, found := "", false
+	for _, attr := range t.Attr {
+		if attr.Key == TagLang || (attr.Key == TagXMLLang && !found) {
+			lang, found = attr.Val, true
+		}
+	}
+	if found {
+		e.setLanguage(TagHTML, lang)
+	}
+}
+
+// setLanguage sets the declared language if it is not set yet, warning about
+// values that are not valid language tags
+func (e *extractor) setLanguage(source, value string) {
+	lang := NormalizeLanguage(value)
+	if len(lang) == 0 || lang == LanguageDefault {
+		if e.options.Warnings && len(strings.TrimSpace(value)) > 0 {
+			e.warn(WarningInvalidLanguage, FieldLanguage, source, fmt.Sprintf("%q is not a valid language tag", value))
+		}
+		return
+	}
+	if len(e.result.Language) == 0 {
+		e.set(&e.result.Language, FieldLanguage, source, lang)
+	}
+}
+
+// normalizeLocale returns the BCP 47 form of a locale, or the locale as it is if it is not valid
+func normalizeLocale(value string) string {
+	if lang := NormalizeLanguage(value); len(lang) > 0 {
+		return lang
+	}
+	return value
+}
+
+// addLink adds a <link> tag with an href to the result, and to the alternates
+// if it has an hreflang
+func (e *extractor) addLink(t html.Token) {
+	var link Link
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagHref:
+			link.Href = truncateField(strings.TrimSpace(attr.Val), MaxFieldLength)
+		case TagHreflang:
+			link.Hreflang = NormalizeLanguage(attr.Val)
+		case TagRel:
+			link.Rel = strings.ToLower(strings.Join(strings.Fields(attr.Val), " "))
+		case TagSizes:
+			link.Sizes = truncateField(attr.Val, MaxFieldLength)
+		case TagType:
+			link.Type = truncateField(attr.Val, MaxFieldLength)
+		}
+	}
+	if len(link.Href) == 0 {
+		return
+	}
+	if e.options.Links {
+		e.result.Links = append(e.result.Links, link)
+	}
+	if e.options.Alternates && len(link.Hreflang) > 0 && slices.Contains(strings.Fields(link.Rel), "alternate") {
+		e.result.Alternates = append(e.result.Alternates, Alternate{Href: link.Href, Language: link.Hreflang})
+	}
+}
+
+// isJSONLDScript returns true if t is a <script type="application/ld+json">
+func isJSONLDScript(t html.Token) bool {
+	for _, attr := range t.Attr {
+		if attr.Key == TagType {
+			mediaType, _, _ := strings.Cut(attr.Val, ";")
+			return strings.EqualFold(strings.TrimSpace(mediaType), TypeJSONLD)
+		}
+	}
+	return false
+}
+
 // truncateField truncates a string to maxLen bytes if it exceeds that limit
 // It handles Unicode properly by ensuring we don't truncate in the middle of a character
 func truncateField(s string, maxLen int) string {
@@ -148,18 +632,17 @@ func truncateField(s string, maxLen int) string {
 	return ""
 }
 
-// extractMetaProperty will extract meta properties from HTML
-func extractMetaProperty(t html.Token, prop string) (content string, ok bool) {
+// metaAttributes returns the name (or property) and content of a meta tag
+func metaAttributes(t html.Token) (key, content string, hasContent bool) {
 	for _, attr := range t.Attr {
-		if (attr.Key == TagProperty && attr.Val == prop) ||
-			(attr.Key == TagName && attr.Val == prop) {
-			ok = true
-		}
-
-		if attr.Key == TagContent {
-			content = attr.Val
+		switch attr.Key {
+		case TagProperty, TagName:
+			if len(key) == 0 {
+				key = attr.Val
+			}
+		case TagContent:
+			content, hasContent = attr.Val, true
 		}
 	}
-
-	return content, ok
+	return key, content, hasContent
 }
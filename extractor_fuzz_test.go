@@ -48,8 +48,8 @@ func FuzzExtract(f *testing.F) {
 	})
 }
 
-// FuzzExtractMetaProperty tests the extractMetaProperty helper function
-func FuzzExtractMetaProperty(f *testing.F) {
+// FuzzNewMetaTag tests reading the attributes of a meta tag
+func FuzzNewMetaTag(f *testing.F) {
 	// Seed with valid HTML token structures
 	f.Add(`name`, `description`, `content`, `Test content`)
 	f.Add(`property`, `og:title`, `content`, `OG Title`)
@@ -81,22 +81,26 @@ func FuzzExtractMetaProperty(f *testing.F) {
 
 		defer func() {
 			if r := recover(); r != nil {
-				t.Errorf("extractMetaProperty panicked: %v", r)
+				t.Errorf("newMetaTag panicked: %v", r)
 			}
 		}()
 
-		content, ok := extractMetaProperty(token, val1)
+		m := newMetaTag(token)
 
 		// Validate results
-		if ok && key2 == TagContent {
-			if content != val2 {
-				t.Errorf("Expected content %q, got %q", val2, content)
+		_, known := metaFieldIndex[val1]
+		if (key1 == TagName || key1 == TagProperty) && known {
+			if m.fields[0] != metaFieldIndex[val1] && m.fields[1] != metaFieldIndex[val1] {
+				t.Errorf("Expected %q to be a field of the meta tag", val1)
+			}
+			if key2 == TagContent && m.content != val2 {
+				t.Errorf("Expected content %q, got %q", val2, m.content)
 			}
 		}
 
 		// Ensure content is valid UTF-8
-		if !utf8.ValidString(content) {
-			t.Errorf("extractMetaProperty returned invalid UTF-8 content")
+		if !utf8.ValidString(m.content) {
+			t.Errorf("newMetaTag returned invalid UTF-8 content")
 		}
 	})
 }
@@ -305,9 +309,11 @@ func FuzzExtractInfiniteLoops(f *testing.F) {
 // validateExtractedTags ensures extracted tag data is safe and valid
 func validateExtractedTags(t *testing.T, tags Tags) {
 	tagFields := []string{
-		tags.Author, tags.Description, tags.OGAuthor, tags.OGDescription,
-		tags.OGImage, tags.OGPublisher, tags.OGSiteName, tags.OGTitle,
-		tags.Title, tags.TwitterDescription, tags.TwitterImage,
+		tags.Author, tags.Description, tags.Language, tags.OGAuthor, tags.OGDescription,
+		tags.OGImage, tags.OGImageHeight, tags.OGImageWidth, tags.OGLocale, tags.OGPublisher,
+		tags.OGSiteName, tags.OGTitle, tags.OGType, tags.OGURL,
+		tags.OGVideo, tags.OGVideoHeight, tags.OGVideoType, tags.OGVideoWidth,
+		tags.ThemeColor, tags.Title, tags.TwitterDescription, tags.TwitterImage,
 		tags.TwitterCard, tags.TwitterPlayer, tags.TwitterPlayerHeight,
 		tags.TwitterPlayerWidth, tags.TwitterTitle,
 	}
@@ -335,9 +341,11 @@ func validateExtractedTags(t *testing.T, tags Tags) {
 // checkForScriptContent ensures no script content is extracted
 func checkForScriptContent(t *testing.T, tags Tags) {
 	tagFields := []string{
-		tags.Author, tags.Description, tags.OGAuthor, tags.OGDescription,
-		tags.OGImage, tags.OGPublisher, tags.OGSiteName, tags.OGTitle,
-		tags.Title, tags.TwitterDescription, tags.TwitterImage,
+		tags.Author, tags.Description, tags.Language, tags.OGAuthor, tags.OGDescription,
+		tags.OGImage, tags.OGImageHeight, tags.OGImageWidth, tags.OGLocale, tags.OGPublisher,
+		tags.OGSiteName, tags.OGTitle, tags.OGType, tags.OGURL,
+		tags.OGVideo, tags.OGVideoHeight, tags.OGVideoType, tags.OGVideoWidth,
+		tags.ThemeColor, tags.Title, tags.TwitterDescription, tags.TwitterImage,
 		tags.TwitterCard, tags.TwitterPlayer, tags.TwitterPlayerHeight,
 		tags.TwitterPlayerWidth, tags.TwitterTitle,
 	}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -474,40 +475,87 @@ func ExampleExtract() {
 	// Output:Austin Rappaport (MrZ)
 }
 
-// BenchmarkExtract benchmarks the method Extract()
+// benchmarkHead is the <head> of a typical article page, with the scripts,
+// styles and links that are read past without being extracted
+var benchmarkHead = `<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="utf-8">
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<title>` + testTitle + ` | TheSite</title>
+		<link rel="preconnect" href="https://cdn.example.com" crossorigin>
+		<link rel="stylesheet" href="/css/main.css?v=123" media="all">
+		<link rel="stylesheet" href="/css/print.css" media="print">
+		<link rel="icon" href="/favicon.ico" sizes="any">
+		<link rel="apple-touch-icon" href="/apple-touch-icon.png">
+		<link rel="canonical" href="https://example.com/articles/test">
+		<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+		<script async src="https://www.googletagmanager.com/gtag/js?id=G-123"></script>
+		<script>window.dataLayer = window.dataLayer || []; function gtag(){dataLayer.push(arguments);}</script>
+		<style>body { margin: 0; font-family: sans-serif; } .hidden { display: none; }</style>
+		<meta name="description" content="Test description">
+		<meta name="author" content="MrZ">
+		<meta name="robots" content="index, follow, max-image-preview:large">
+		<meta name="theme-color" content="#ffffff">
+		<meta property="og:title" content="OG Test Title">
+		<meta property="og:description" content="OG Test description">
+		<meta property="og:image" content="` + testImageURL + `">
+		<meta property="og:image:width" content="1200">
+		<meta property="og:image:height" content="630">
+		<meta property="og:type" content="article">
+		<meta property="og:url" content="https://example.com/articles/test">
+		<meta property="og:site_name" content="TheSite">
+		<meta property="og:locale" content="en_US">
+		<meta property="article:published_time" content="2024-05-12T09:30:00Z">
+		<meta name="twitter:card" content="summary_large_image">
+		<meta name="twitter:title" content="OG Test Title">
+		<meta name="twitter:description" content="OG Test description">
+		<meta name="twitter:image" content="` + testImageURL + `">
+		<meta name="twitter:creator" content="` + testHandle + `">
+		<script type="application/ld+json">{"@context":"https://schema.org","@type":"NewsArticle","headline":"OG Test Title","author":{"@type":"Person","name":"MrZ"},"datePublished":"2024-05-12T09:30:00Z"}</script>
+		<script src="/js/app.js" defer></script>
+	</head>
+	<body class="article">
+		<header><nav><a href="/">Home</a> <a href="/news">News</a></nav></header>
+		<main>
+			<article>
+				<h1>OG Test Title</h1>
+				<p class="byline">By <a href="/authors/mrz">MrZ</a> | <time datetime="2024-05-12">May 12, 2024</time></p>
+`
+
+// benchmarkPage is a whole article page
+var benchmarkPage = benchmarkHead + strings.Repeat(`				<p>Lorem ipsum dolor sit amet, <a href="/x">consectetur</a> adipiscing elit, sed do eiusmod tempor
+				incididunt ut labore et dolore magna aliqua. <img src="/img/photo.jpg" width="800" height="600" alt="Photo"></p>
+`, 20) + `			</article>
+		</main>
+		<footer><p>&copy; TheSite</p></footer>
+	</body>
+</html>`
+
+// BenchmarkExtract benchmarks the method Extract() and ExtractWithOptions() on a typical page
 func BenchmarkExtract(b *testing.B) {
-	title := testTitle
-	description := "Test description"
-	ogTitle := "OG Test Title"
-	ogDesc := "OG Test description"
-	ogImage := testImageURL
-	ogAuthor := "MrZ"
-	ogPublisher := testHandle
-	ogSiteName := "TheSite"
-
-	mp := NewMockPage(`
-	<html>
-		<head>
-			<title>` + title + `</title>
-			<meta property="description" content="` + description + `" />
-			<meta property="og:title" content="` + ogTitle + `" />
-			<meta property="twitter:title" content="` + ogTitle + `" />
-			<meta property="og:description" content="` + ogDesc + `" />
-			<meta property="twitter:description" content="` + ogDesc + `" />
-			<meta property="og:image" content="` + ogImage + `" />
-			<meta property="twitter:image" content="` + ogImage + `" />
-			<meta property="og:author" content="` + ogAuthor + `" />
-			<meta property="og:publisher" content="` + ogPublisher + `" />
-			<meta property="og:site_name" content="` + ogSiteName + `" />
-		</head>
-		<body>
-			We're testing!
-		</body>
-	</html>`)
-
-	for i := 0; i < b.N; i++ {
-		_ = Extract(&mp)
-	}
+	b.Run("default", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Extract(strings.NewReader(benchmarkPage))
+		}
+	})
+
+	b.Run("provenance and warnings", func(b *testing.B) {
+		options := ExtractOptions{Provenance: true, Warnings: true}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = ExtractWithOptions(strings.NewReader(benchmarkPage), options)
+		}
+	})
+
+	b.Run("body options", func(b *testing.B) {
+		options := ExtractOptions{Authors: true, Dates: true, Fallbacks: true, Keywords: true}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = ExtractWithOptions(strings.NewReader(benchmarkPage), options)
+		}
+	})
 }
 
 // TestTruncateField tests the truncateField helper function
@@ -604,3 +652,288 @@ func TestLargeFieldTruncation(t *testing.T) {
 	assert.Equal(t, strings.Repeat("A", MaxFieldLength), tags3.OGTitle)
 	assert.Equal(t, strings.Repeat("A", MaxFieldLength), tags3.Title)
 }
+
+// TestExtractWithOptionsProvenance tests recording where each field came from
+func TestExtractWithOptionsProvenance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("provenance is disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<html><head><title>`+testTitle+`</title></head></html>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Equal(t, testTitle, result.Title)
+		assert.Nil(t, result.Provenance)
+	})
+
+	t.Run("title, description and image sources", func(t *testing.T) {
+		page := "<html>\n<head>\n" +
+			`<meta property="twitter:title" content="Twitter Title">` + "\n" +
+			`<meta property="og:description" content="OG Description">` + "\n" +
+			`<meta property="twitter:image" content="` + testImageURL + `">` + "\n" +
+			"<title>" + testTitle + "</title>\n" +
+			"</head></html>"
+
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Provenance: true})
+		require.NotNil(t, result)
+		assert.Equal(t, testTitle, result.Title)
+		assert.Equal(t, "OG Description", result.Description)
+		assert.Equal(t, testImageURL, result.OGImage)
+
+		assert.Equal(t, Provenance{Line: 6, Offset: strings.Index(page, testTitle), Source: TagTitle}, result.Provenance[FieldTitle])
+		assert.Equal(t, Provenance{Line: 3, Offset: strings.Index(page, `<meta property="twitter:title"`), Source: TagTwitterTitle}, result.Provenance[FieldTwitterTitle])
+		assert.Equal(t, Provenance{Line: 4, Offset: strings.Index(page, `<meta property="og:description"`), Source: TagOGDescription}, result.Provenance[FieldDescription])
+		assert.Equal(t, TagTwitterImage, result.Provenance[FieldOGImage].Source)
+		assert.Equal(t, TagTwitterImage, result.Provenance[FieldTwitterImage].Source)
+		assert.NotContains(t, result.Provenance, FieldAuthor)
+	})
+
+	t.Run("fallback sources", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta property="og:title" content="OG"><meta name="og:author" content="`+testAuthor+`"></head>`), ExtractOptions{Provenance: true})
+		require.NotNil(t, result)
+		assert.Equal(t, TagOGTitle, result.Provenance[FieldTitle].Source)
+		assert.Equal(t, TagOGAuthor, result.Provenance[FieldAuthor].Source)
+		assert.Equal(t, 1, result.Provenance[FieldAuthor].Line)
+	})
+
+	t.Run("truncated values are flagged", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<meta name="description" content="`+strings.Repeat("A", MaxFieldLength+1)+`">`), ExtractOptions{Provenance: true})
+		require.NotNil(t, result)
+		assert.Len(t, result.Description, MaxFieldLength)
+		assert.True(t, result.Provenance[FieldDescription].Truncated)
+	})
+}
+
+// TestExtractWithOptionsWarnings tests reporting problems in the document's metadata
+func TestExtractWithOptionsWarnings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected []Warning
+	}{
+		{
+			name:     "clean document",
+			mockHTML: `<html><head><title>` + testTitle + `</title><meta property="og:title" content="` + testTitle + `"><meta property="og:title" content="` + testTitle + `"></head></html>`,
+			expected: nil,
+		},
+		{
+			name:     "duplicate conflicting tag",
+			mockHTML: "<head>\n<meta property=\"og:title\" content=\"First\">\n<meta property=\"og:title\" content=\"Second\">\n</head>",
+			expected: []Warning{{Code: WarningDuplicateTag, Field: FieldOGTitle, Tag: TagOGTitle, Line: 3, Offset: 50, Message: `duplicate og:title overrides "First" with "Second"`}},
+		},
+		{
+			name:     "duplicate title",
+			mockHTML: `<head><title>First</title><title>Second</title></head>`,
+			expected: []Warning{{Code: WarningDuplicateTag, Field: FieldTitle, Tag: TagTitle, Line: 1, Offset: 33, Message: `duplicate title "Second" is ignored, keeping "First"`}},
+		},
+		{
+			name:     "empty content attribute",
+			mockHTML: `<head><meta name="description" content=" "></head>`,
+			expected: []Warning{{Code: WarningEmptyContent, Field: FieldDescription, Tag: TagMetaDescription, Line: 1, Offset: 6, Message: "meta tag has an empty content attribute"}},
+		},
+		{
+			name:     "missing content attribute",
+			mockHTML: `<head><meta property="og:image"></head>`,
+			expected: []Warning{{Code: WarningMissingContent, Field: FieldOGImage, Tag: TagOGImage, Line: 1, Offset: 6, Message: "meta tag has no content attribute"}},
+		},
+		{
+			name:     "unknown meta tags are ignored",
+			mockHTML: `<head><meta name="viewport"><meta charset="utf-8"><meta name="robots" content=""></head>`,
+			expected: nil,
+		},
+		{
+			name:     "title outside of the head",
+			mockHTML: `<html><head></head><title>` + testTitle + `</title></html>`,
+			expected: []Warning{{Code: WarningTitleInBody, Field: FieldTitle, Tag: TagTitle, Line: 1, Offset: 19, Message: "<title> found outside of the <head>"}},
+		},
+		{
+			name:     "truncated field",
+			mockHTML: `<meta name="author" content="` + strings.Repeat("A", MaxFieldLength+1) + `">`,
+			expected: []Warning{{Code: WarningTruncated, Field: FieldAuthor, Tag: TagMetaAuthor, Line: 1, Offset: 0, Message: "value truncated from 10001 to 10000 bytes"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{Warnings: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.Warnings)
+
+			// Warnings are only collected when enabled
+			assert.Empty(t, ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{}).Warnings)
+		})
+	}
+}
+
+// TestOGImageAndVideo tests the extraction of the image dimensions, video and theme color
+func TestOGImageAndVideo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected Tags
+	}{
+		{
+			name: "image and video",
+			mockHTML: `<head>
+				<meta property="og:image" content="` + testImageURL + `">
+				<meta property="og:image:width" content="1200">
+				<meta property="og:image:height" content="630">
+				<meta property="og:video" content="https://example.com/video.mp4">
+				<meta property="og:video:type" content="video/mp4">
+				<meta property="og:video:width" content="1280">
+				<meta property="og:video:height" content="720">
+				<meta name="theme-color" content="#ffffff" media="(prefers-color-scheme: light)">
+				<meta name="theme-color" content="#000000" media="(prefers-color-scheme: dark)">
+			</head>`,
+			expected: Tags{
+				OGImage:       testImageURL,
+				OGImageHeight: "630",
+				OGImageWidth:  "1200",
+				OGVideo:       "https://example.com/video.mp4",
+				OGVideoHeight: "720",
+				OGVideoType:   "video/mp4",
+				OGVideoWidth:  "1280",
+				ThemeColor:    "#ffffff",
+			},
+		},
+		{
+			name:     "video url fallbacks",
+			mockHTML: `<head><meta property="og:video:secure_url" content="https://example.com/secure.mp4"><meta property="og:video:url" content="http://example.com/video.mp4"></head>`,
+			expected: Tags{OGVideo: "https://example.com/secure.mp4"},
+		},
+		{
+			name:     "og:video wins over its fallbacks",
+			mockHTML: `<head><meta property="og:video:url" content="https://example.com/url.mp4"><meta property="og:video" content="https://example.com/video.mp4"></head>`,
+			expected: Tags{OGVideo: "https://example.com/video.mp4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Extract(strings.NewReader(test.mockHTML)))
+		})
+	}
+}
+
+// TestExtractWithOptionsLinksAndJSONLD tests collecting the <link> tags and JSON-LD blocks
+func TestExtractWithOptionsLinksAndJSONLD(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><head>
+		<link rel="Shortcut  Icon" href=" /favicon.png " type="image/png" sizes="32x32">
+		<link rel="canonical" href="https://example.com/story">
+		<link rel="stylesheet">
+		<script>var notJSONLD = true;</script>
+		<script type="application/ld+json; charset=utf-8">{"@type":"NewsArticle","headline":"Story"}</script>
+		<script type="application/ld+json"></script>
+		<title>` + testTitle + `</title>
+	</head><body><link rel="icon" href="/body.png"></body></html>`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Links)
+		assert.Nil(t, result.JSONLD)
+		assert.Equal(t, testTitle, result.Title)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{JSONLD: true, Links: true})
+		require.NotNil(t, result)
+		assert.Equal(t, []Link{
+			{Href: "/favicon.png", Rel: "shortcut icon", Sizes: "32x32", Type: "image/png"},
+			{Href: "https://example.com/story", Rel: "canonical"},
+		}, result.Links)
+		assert.Equal(t, []string{`{"@type":"NewsArticle","headline":"Story"}`}, result.JSONLD)
+		assert.Equal(t, testTitle, result.Title)
+	})
+
+	t.Run("oversized JSON-LD blocks are skipped", func(t *testing.T) {
+		block := `{"@type":"Thing","name":"` + strings.Repeat("A", MaxJSONLDLength) + `"}`
+		result := ExtractWithOptions(strings.NewReader(`<script type="application/ld+json">`+block+`</script>`), ExtractOptions{JSONLD: true})
+		require.NotNil(t, result)
+		assert.Empty(t, result.JSONLD)
+	})
+}
+
+// TestTitleHandling tests empty, nested, repeated and unterminated titles
+func TestTitleHandling(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected string
+	}{
+		{"entities", `<head><title>Rock &amp; Roll &lt;3</title></head>`, "Rock & Roll <3"},
+		{"markup is kept as text", `<head><title>A <b>bold</b> title</title></head>`, "A <b>bold</b> title"},
+		{"first title wins", `<head><title>First</title><title>Second</title></head>`, "First"},
+		{"blank titles are skipped", `<head><title> </title><title>Second</title></head>`, "Second"},
+		{"empty title does not capture the next text", "<head><title></title>\n<meta name=\"author\" content=\"x\"></head>", ""},
+		{"empty title keeps the og:title fallback", `<head><meta property="og:title" content="OG"><title></title></head>`, "OG"},
+		{"document title replaces the og:title fallback", `<head><meta property="og:title" content="OG"><title>Title</title></head>`, "Title"},
+		{"svg title is ignored", `<head><svg><title>Icon</title></svg><title>Title</title></head>`, "Title"},
+		{"nested svg title is ignored", `<head><svg><g><svg><title>Icon</title></svg><title>Group</title></g></svg><title>Title</title></head>`, "Title"},
+		{"self-closing svg", `<head><svg/><title>Title</title></head>`, "Title"},
+		{"math title is ignored", `<head><math><title>Formula</title></math></head>`, ""},
+		{"unbalanced svg end tags", `<head></svg></svg><title>Title</title></head>`, "Title"},
+		{"unterminated title", `<head><title>Unterminated`, "Unterminated"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Extract(strings.NewReader(test.mockHTML)).Title)
+		})
+	}
+}
+
+// TestExtractWithOptionsScanBody tests reading metadata emitted after <body>
+func TestExtractWithOptionsScanBody(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><head><meta name="description" content="` + testDescription + `"></head>
+		<body>
+			<svg><title>Icon</title></svg>
+			<title>` + testTitle + `</title>
+			<meta property="og:image" content="` + testImageURL + `">
+			<script type="application/ld+json">{"@type":"Thing"}</script>
+			<p>` + strings.Repeat("Filler ", 100) + `</p>
+			<meta name="author" content="` + testAuthor + `">
+		</body></html>`
+
+	t.Run("stops at the body by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{JSONLD: true})
+		require.NotNil(t, result)
+		assert.Equal(t, Tags{Description: testDescription}, result.Tags)
+		assert.Empty(t, result.JSONLD)
+	})
+
+	t.Run("whole document", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{JSONLD: true, ScanBody: true, Warnings: true})
+		require.NotNil(t, result)
+		assert.Equal(t, testTitle, result.Title)
+		assert.Equal(t, testDescription, result.Description)
+		assert.Equal(t, testImageURL, result.OGImage)
+		assert.Equal(t, testAuthor, result.Author)
+		assert.Equal(t, []string{`{"@type":"Thing"}`}, result.JSONLD)
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningTitleInBody, result.Warnings[0].Code)
+	})
+
+	t.Run("scan limit", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{ScanBody: true, ScanLimit: 400})
+		require.NotNil(t, result)
+		assert.Equal(t, testTitle, result.Title)
+		assert.Equal(t, testImageURL, result.OGImage)
+		assert.Empty(t, result.Author, "the author is past the scan limit")
+	})
+
+	t.Run("documents without a head or body", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<!DOCTYPE html><title>`+testTitle+`</title><p>Text<meta name="author" content="`+testAuthor+`">`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Equal(t, testTitle, result.Title)
+		assert.Equal(t, testAuthor, result.Author)
+	})
+}
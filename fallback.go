@@ -0,0 +1,400 @@
+package metaextractor
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Fallback limits
+const (
+	MaxFallbackDescriptionLength = 300 // Longer paragraphs are shortened at a word boundary
+	MinFallbackImageHeight       = 100 // Smaller images are icons, spacers or tracking pixels
+	MinFallbackImageWidth        = 200
+	MinFallbackParagraphLength   = 80 // Shorter paragraphs are usually bylines, captions or labels
+	minFallbackParagraphWords    = 8
+	maxFallbackImageAspectRatio  = 4 // Wider images are usually banners
+)
+
+// boilerplateTags are the elements whose content is never the main content of a page
+var boilerplateTags = map[string]bool{
+	"aside": true, "button": true, "dialog": true, "figcaption": true, "footer": true, "form": true,
+	"header": true, "iframe": true, "math": true, "nav": true, "noscript": true, "script": true,
+	"select": true, "style": true, "svg": true, "template": true, "textarea": true,
+}
+
+// boilerplateRoles are the ARIA roles of elements that are not the main content
+var boilerplateRoles = map[string]bool{
+	"alert": true, "alertdialog": true, "banner": true, "complementary": true,
+	"contentinfo": true, "dialog": true, "navigation": true, "search": true,
+}
+
+// boilerplateNames are the prefixes of class and id words used for navigation,
+// cookie banners and other boilerplate (e.g. "cookie-banner" or "navbar")
+var boilerplateNames = []string{
+	"advert", "banner", "breadcrumb", "comment", "consent", "cookie", "disclaimer", "footer", "gdpr",
+	"header", "menu", "modal", "nav", "newsletter", "popup", "promo", "related", "share", "sidebar",
+	"social", "subscribe", "toolbar",
+}
+
+// contentNames are class and id words that mark the main content, which is
+// never skipped even if it also has a boilerplate name (e.g. "post has-comments")
+var contentNames = map[string]bool{
+	"article": true, "content": true, "entry": true, "main": true, "post": true, "story": true,
+}
+
+// contentTags are never skipped because of their class or id
+var contentTags = map[string]bool{"article": true, "body": true, "html": true, "main": true}
+
+// blockTags are the elements that end an open paragraph
+var blockTags = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "div": true, "dl": true, "fieldset": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "li": true,
+	"main": true, "ol": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// voidTags are the elements that never have an end tag
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// trackingURLParts are found in the URLs of tracking pixels and spacer images
+var trackingURLParts = []string{"1x1", "beacon", "blank.gif", "pixel", "spacer", "tracking", "transparent.gif"}
+
+// fallbackState holds the candidates found in the body for the Fallbacks option
+type fallbackState struct {
+	description       string
+	descriptionLine   int
+	descriptionOffset int
+	image             bodyImage
+	inParagraph       bool
+	paragraph         []byte // Text of the current paragraph
+	paragraphLine     int
+	paragraphOffset   int
+	skipDepth         int    // Depth of nested skipTag elements being skipped
+	skipTag           string // Boilerplate element being skipped
+}
+
+// bodyImage is an <img> that may be used as the image of the page
+type bodyImage struct {
+	height int // Zero if unknown
+	line   int
+	offset int
+	url    string
+	width  int // Zero if unknown
+}
+
+// fallbacksDone returns true once there is a description and an image
+func (e *extractor) fallbacksDone() bool {
+	return (len(e.result.Description) > 0 || len(e.fallback.description) > 0) &&
+		(len(e.result.OGImage) > 0 || len(e.fallback.image.url) > 0)
+}
+
+// fallbackStartTag looks for paragraphs and images outside of boilerplate elements
+func (e *extractor) fallbackStartTag(t html.Token, tt html.TokenType) {
+	f := &e.fallback
+	if f.skipDepth > 0 {
+		if t.Data == f.skipTag && tt == html.StartTagToken {
+			f.skipDepth++
+		}
+		return
+	}
+	if isBoilerplate(t) {
+		e.endParagraph()
+		if tt == html.StartTagToken && !voidTags[t.Data] {
+			f.skipTag, f.skipDepth = t.Data, 1
+		}
+		return
+	}
+
+	switch {
+	case t.Data == TagParagraph:
+		e.endParagraph()
+		if len(f.description) == 0 {
+			f.inParagraph = true
+			f.paragraph = f.paragraph[:0]
+			f.paragraphLine, f.paragraphOffset = e.line, e.offset
+		}
+	case t.Data == TagImg:
+		if image, ok := parseBodyImage(t); ok && len(f.image.url) == 0 {
+			image.line, image.offset = e.line, e.offset
+			f.image = image
+		}
+	case t.Data == "br":
+		e.fallbackText([]byte{' '})
+	case blockTags[t.Data]:
+		e.endParagraph()
+	}
+}
+
+// fallbackEndTag closes boilerplate elements and paragraphs
+func (e *extractor) fallbackEndTag(name string) {
+	f := &e.fallback
+	if f.skipDepth > 0 {
+		if name == f.skipTag {
+			f.skipDepth--
+		}
+		return
+	}
+	if name == TagParagraph || blockTags[name] {
+		e.endParagraph()
+	}
+}
+
+// fallbackText adds text to the current paragraph
+func (e *extractor) fallbackText(text []byte) {
+	f := &e.fallback
+	if f.skipDepth == 0 && f.inParagraph && len(f.paragraph) < MaxFieldLength {
+		f.paragraph = append(f.paragraph, text...)
+	}
+}
+
+// endParagraph closes the current paragraph, keeping it as the description
+// if it is the first one that is long enough
+func (e *extractor) endParagraph() {
+	f := &e.fallback
+	if !f.inParagraph {
+		return
+	}
+	f.inParagraph = false
+
+	text := NormalizeText(string(f.paragraph))
+	if utf8.RuneCountInString(text) < MinFallbackParagraphLength || len(strings.Fields(text)) < minFallbackParagraphWords {
+		return
+	}
+	f.description = shortenText(text, MaxFallbackDescriptionLength)
+	f.descriptionLine, f.descriptionOffset = f.paragraphLine, f.paragraphOffset
+}
+
+// applyFallbacks fills a missing description and image with the candidates
+// found in the body, flagging them as heuristic
+func (e *extractor) applyFallbacks() {
+	e.endParagraph()
+	f := &e.fallback
+	tags := &e.result.Tags
+
+	if len(tags.Description) == 0 && len(f.description) > 0 {
+		e.line, e.offset = f.descriptionLine, f.descriptionOffset
+		e.set(&tags.Description, FieldDescription, TagParagraph, f.description)
+		e.result.Heuristic = append(e.result.Heuristic, FieldDescription)
+	}
+
+	if len(tags.OGImage) == 0 && len(f.image.url) > 0 {
+		e.line, e.offset = f.image.line, f.image.offset
+		e.set(&tags.OGImage, FieldOGImage, TagImg, resolveURL(e.fallbackBase(), f.image.url))
+		if f.image.width > 0 && len(tags.OGImageWidth) == 0 {
+			e.set(&tags.OGImageWidth, FieldOGImageWidth, TagImg, strconv.Itoa(f.image.width))
+		}
+		if f.image.height > 0 && len(tags.OGImageHeight) == 0 {
+			e.set(&tags.OGImageHeight, FieldOGImageHeight, TagImg, strconv.Itoa(f.image.height))
+		}
+		e.result.Heuristic = append(e.result.Heuristic, FieldOGImage)
+	}
+}
+
+// fallbackBase returns the address relative body images are resolved against,
+// the page URL or og:url when it is missing (nil if neither is absolute)
+func (e *extractor) fallbackBase() *url.URL {
+	for _, rawURL := range []string{e.options.URL, e.result.OGURL} {
+		if u, err := url.Parse(strings.TrimSpace(rawURL)); err == nil && u.IsAbs() {
+			return u
+		}
+	}
+	return nil
+}
+
+// isBoilerplate returns true for elements that hold navigation, cookie banners,
+// comments and other content that does not describe the page
+func isBoilerplate(t html.Token) bool {
+	if boilerplateTags[t.Data] {
+		return true
+	}
+	if contentTags[t.Data] {
+		return false
+	}
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if strings.EqualFold(attr.Val, "true") {
+				return true
+			}
+		case "role":
+			if boilerplateRoles[strings.ToLower(strings.TrimSpace(attr.Val))] {
+				return true
+			}
+		case "class", "id":
+			if hasBoilerplateName(attr.Val) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasBoilerplateName returns true if a word of a class or id starts with a
+// boilerplate name, unless another word marks it as content
+func hasBoilerplateName(value string) bool {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	found := false
+	for _, word := range words {
+		if contentNames[word] {
+			return false
+		}
+		for _, name := range boilerplateNames {
+			if strings.HasPrefix(word, name) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// parseBodyImage returns the image of an <img> tag if it is large enough to
+// represent the page, using the largest srcset candidate when there is one
+//
+// Images without any known size are skipped since they are as likely to be icons
+func parseBodyImage(t html.Token) (bodyImage, bool) {
+	var src, lazySrc, srcset string
+	var width, height int
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case "src":
+			src = strings.TrimSpace(attr.Val)
+		case "data-src":
+			lazySrc = strings.TrimSpace(attr.Val)
+		case "srcset", "data-srcset":
+			if len(srcset) == 0 {
+				srcset = attr.Val
+			}
+		case "width":
+			width = parseDimension(attr.Val)
+		case "height":
+			height = parseDimension(attr.Val)
+		}
+	}
+
+	// Lazy loaded images use a placeholder as their src
+	if len(src) == 0 || strings.HasPrefix(src, "data:") {
+		src = lazySrc
+	}
+	image := bodyImage{height: height, url: src, width: width}
+	if candidate, candidateWidth := largestSrcsetCandidate(srcset); len(candidate) > 0 && candidateWidth > width {
+		image.url, image.width = candidate, candidateWidth
+		if width > 0 {
+			image.height = height * candidateWidth / width
+		}
+	}
+
+	switch {
+	case len(image.url) == 0 || strings.HasPrefix(image.url, "data:") || isTrackingURL(image.url):
+		return image, false
+	case image.width < MinFallbackImageWidth:
+		return image, false
+	case height > 0 && height < MinFallbackImageHeight:
+		return image, false
+	case width > 0 && height > 0 && width > maxFallbackImageAspectRatio*height:
+		return image, false
+	}
+	return image, true
+}
+
+// largestSrcsetCandidate returns the URL and width of the srcset candidate with
+// the largest width descriptor (the width is zero if there are none)
+func largestSrcsetCandidate(srcset string) (string, int) {
+	best, bestWidth := "", 0
+	for rest := srcset; ; {
+		var candidate string
+		var descriptors []string
+		if candidate, descriptors, rest = nextSrcsetCandidate(rest); len(candidate) == 0 {
+			break
+		}
+		width := 0
+		if len(descriptors) > 0 && strings.HasSuffix(descriptors[0], "w") {
+			width, _ = strconv.Atoi(strings.TrimSuffix(descriptors[0], "w"))
+		}
+		if len(best) == 0 || width > bestWidth {
+			best, bestWidth = candidate, width
+		}
+	}
+	return best, bestWidth
+}
+
+// nextSrcsetCandidate returns the URL and descriptors of the first candidate of
+// a srcset, and the candidates after it
+//
+// As in the HTML specification, the URL runs to the next whitespace so it may
+// contain commas (as CDN URLs like "w_800,h_600" do), and only a comma after
+// the descriptors, outside of parentheses, separates candidates
+func nextSrcsetCandidate(srcset string) (candidate string, descriptors []string, rest string) {
+	srcset = strings.TrimLeft(srcset, asciiSpace+",")
+	end := strings.IndexAny(srcset, asciiSpace)
+	if end < 0 {
+		end = len(srcset)
+	}
+	candidate, rest = srcset[:end], srcset[end:]
+
+	// A URL ending with a comma has no descriptors
+	if trimmed := strings.TrimRight(candidate, ","); len(trimmed) < len(candidate) {
+		return trimmed, nil, rest
+	}
+
+	depth := 0
+	end = strings.IndexFunc(rest, func(r rune) bool {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth = max(depth-1, 0)
+		case ',':
+			return depth == 0
+		}
+		return false
+	})
+	if end < 0 {
+		return candidate, strings.Fields(rest), ""
+	}
+	return candidate, strings.Fields(rest[:end]), rest[end+1:]
+}
+
+// isTrackingURL returns true for URLs that look like tracking pixels or spacers
+func isTrackingURL(rawURL string) bool {
+	rawURL = strings.ToLower(rawURL)
+	for _, part := range trackingURLParts {
+		if strings.Contains(rawURL, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// shortenText shortens text to at most maxLength characters, cutting at a word
+// boundary and adding an ellipsis
+func shortenText(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	end, cut, count := 0, 0, 0
+	for i, r := range text {
+		if count == maxLength-1 { // Leave room for the ellipsis
+			end = i
+			break
+		}
+		if r == ' ' {
+			cut = i
+		}
+		count++
+	}
+	if cut == 0 {
+		cut = end
+	}
+	return strings.TrimRight(text[:cut], " ,;:.-") + "…"
+}
@@ -0,0 +1,222 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const testParagraph = "The council approved the new budget on Tuesday after a long debate about school funding and road repairs."
+
+// TestExtractWithOptionsFallbacks tests deriving the description and image from the body
+func TestExtractWithOptionsFallbacks(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><head><title>` + testTitle + `</title></head>
+<body class="has-sidebar">
+	<header><p>` + strings.Repeat("Header text that should never be used. ", 5) + `</p></header>
+	<nav><img src="/logo.png" width="400" height="300"></nav>
+	<div id="cookie-banner"><p>` + strings.Repeat("We use cookies to improve your experience. ", 5) + `</p><div><p>nested</p></div></div>
+	<img src="/pixel.gif" width="1" height="1">
+	<img src="/icon.png">
+	<img src="data:image/gif;base64,R0lGOD" data-src="/lazy-small.jpg" width="50" height="50">
+	<article class="post has-comments">
+		<p>By Jane Doe</p>
+		<img src="/story-small.jpg" srcset="/story-800.jpg 800w, /story-1600.jpg 1600w" width="400" height="250">
+		<p>` + testParagraph + `<br>It <b>passed</b> 7&ndash;2.</p>
+		<p>Second paragraph.</p>
+	</article>
+</body></html>`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Empty(t, result.Description)
+		assert.Empty(t, result.OGImage)
+		assert.Nil(t, result.Heuristic)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Fallbacks: true, Provenance: true})
+		require.NotNil(t, result)
+		assert.Equal(t, testTitle, result.Title)
+		assert.Equal(t, testParagraph+" It passed 7–2.", result.Description)
+		assert.Equal(t, "/story-1600.jpg", result.OGImage)
+		assert.Equal(t, "1600", result.OGImageWidth)
+		assert.Equal(t, "1000", result.OGImageHeight)
+		assert.Equal(t, []string{FieldDescription, FieldOGImage}, result.Heuristic)
+
+		assert.Equal(t, TagParagraph, result.Provenance[FieldDescription].Source)
+		assert.Equal(t, strings.Index(page, "<p>"+testParagraph), result.Provenance[FieldDescription].Offset)
+		assert.Equal(t, TagImg, result.Provenance[FieldOGImage].Source)
+		assert.Equal(t, 11, result.Provenance[FieldOGImage].Line)
+	})
+
+	t.Run("relative image resolved against the URL", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Fallbacks: true, URL: "https://example.com/news/story"})
+		require.NotNil(t, result)
+		assert.Equal(t, "https://example.com/story-1600.jpg", result.OGImage)
+
+		withOGURL := strings.Replace(page, "</head>", `<meta property="og:url" content="https://example.org/news/story"></head>`, 1)
+		result = ExtractWithOptions(strings.NewReader(withOGURL), ExtractOptions{Fallbacks: true})
+		require.NotNil(t, result)
+		assert.Equal(t, "https://example.org/story-1600.jpg", result.OGImage)
+	})
+
+	t.Run("metadata wins", func(t *testing.T) {
+		withMeta := strings.Replace(page, "</head>", `<meta name="description" content="`+testDescription+`"><meta property="og:image" content="`+testImageURL+`"></head>`, 1)
+		result := ExtractWithOptions(strings.NewReader(withMeta), ExtractOptions{Fallbacks: true})
+		require.NotNil(t, result)
+		assert.Equal(t, testDescription, result.Description)
+		assert.Equal(t, testImageURL, result.OGImage)
+		assert.Empty(t, result.OGImageWidth)
+		assert.Nil(t, result.Heuristic)
+	})
+
+	t.Run("metadata after the body wins in ScanBody mode", func(t *testing.T) {
+		late := strings.Replace(page, "</body>", `<meta name="description" content="`+testDescription+`"></body>`, 1)
+		result := ExtractWithOptions(strings.NewReader(late), ExtractOptions{Fallbacks: true, ScanBody: true})
+		require.NotNil(t, result)
+		assert.Equal(t, testDescription, result.Description)
+		assert.Equal(t, "/story-1600.jpg", result.OGImage)
+		assert.Equal(t, []string{FieldOGImage}, result.Heuristic)
+	})
+
+	t.Run("scan limit", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Fallbacks: true, ScanLimit: 200})
+		require.NotNil(t, result)
+		assert.Empty(t, result.Description)
+		assert.Empty(t, result.OGImage)
+	})
+
+	t.Run("long paragraphs are shortened", func(t *testing.T) {
+		long := "<body><p>" + strings.Repeat("word ", 200) + "</p>"
+		result := ExtractWithOptions(strings.NewReader(long), ExtractOptions{Fallbacks: true, Normalize: true})
+		require.NotNil(t, result)
+		assert.LessOrEqual(t, utf8.RuneCountInString(result.Description), MaxFallbackDescriptionLength)
+		assert.True(t, strings.HasSuffix(result.Description, "word…"))
+	})
+
+	t.Run("unclosed paragraph at the end of the document", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader("<body><p>"+testParagraph), ExtractOptions{Fallbacks: true})
+		require.NotNil(t, result)
+		assert.Equal(t, testParagraph, result.Description)
+	})
+}
+
+// TestParseBodyImage tests picking images that can represent a page
+func TestParseBodyImage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		tag      string
+		expected bodyImage
+		ok       bool
+	}{
+		{"large", `<img src="/a.jpg" width="800" height="600">`, bodyImage{url: "/a.jpg", width: 800, height: 600}, true},
+		{"width only", `<img src="/a.jpg" width="800px">`, bodyImage{url: "/a.jpg", width: 800}, true},
+		{"unknown size", `<img src="/a.jpg">`, bodyImage{url: "/a.jpg"}, false},
+		{"percentage size", `<img src="/a.jpg" width="100%">`, bodyImage{url: "/a.jpg"}, false},
+		{"too narrow", `<img src="/a.jpg" width="150" height="600">`, bodyImage{url: "/a.jpg", width: 150, height: 600}, false},
+		{"too short", `<img src="/a.jpg" width="600" height="50">`, bodyImage{url: "/a.jpg", width: 600, height: 50}, false},
+		{"banner", `<img src="/a.jpg" width="970" height="200">`, bodyImage{url: "/a.jpg", width: 970, height: 200}, false},
+		{"tracking pixel", `<img src="https://example.com/tracking.gif?id=1" width="300" height="300">`, bodyImage{url: "https://example.com/tracking.gif?id=1", width: 300, height: 300}, false},
+		{"srcset", `<img src="/s.jpg" srcset="/m.jpg 600w,/l.jpg 1200w , /s.jpg 300w">`, bodyImage{url: "/l.jpg", width: 1200}, true},
+		{"srcset density descriptors", `<img src="/s.jpg" srcset="/s.jpg 1x, /l.jpg 2x" width="300" height="200">`, bodyImage{url: "/s.jpg", width: 300, height: 200}, true},
+		{"srcset urls with commas", `<img src="/s.jpg" srcset="https://res.cloudinary.com/demo/image/upload/w_400,h_300/a.jpg 400w,https://res.cloudinary.com/demo/image/upload/w_800,h_600/a.jpg 800w">`, bodyImage{url: "https://res.cloudinary.com/demo/image/upload/w_800,h_600/a.jpg", width: 800}, true},
+		{"lazy loaded", `<img src="data:image/gif;base64,R0lGOD" data-src="/lazy.jpg" data-srcset="/lazy-900.jpg 900w">`, bodyImage{url: "/lazy-900.jpg", width: 900}, true},
+		{"data uri", `<img src="data:image/png;base64,iVBOR" width="800" height="600">`, bodyImage{url: "", width: 800, height: 600}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			z := html.NewTokenizer(strings.NewReader(test.tag))
+			z.Next()
+			image, ok := parseBodyImage(z.Token())
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, image)
+		})
+	}
+}
+
+// TestIsBoilerplate tests detecting elements that are not the main content
+func TestIsBoilerplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tag      string
+		expected bool
+	}{
+		{`<nav>`, true},
+		{`<footer class="article">`, true},
+		{`<div>`, false},
+		{`<div class="cookie-consent">`, true},
+		{`<div id="CookieBanner">`, true},
+		{`<div class="navbar">`, true},
+		{`<div class="canvas">`, false},
+		{`<div class="post has-comments">`, false},
+		{`<section class="comments">`, true},
+		{`<div role="navigation">`, true},
+		{`<div role="main">`, false},
+		{`<div hidden>`, true},
+		{`<div aria-hidden="true">`, true},
+		{`<body class="has-sidebar nav-open">`, false},
+		{`<article class="related-stories">`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			z := html.NewTokenizer(strings.NewReader(test.tag))
+			z.Next()
+			assert.Equal(t, test.expected, isBoilerplate(z.Token()))
+		})
+	}
+}
+
+// TestShortenText tests shortening text at a word boundary
+func TestShortenText(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "short", shortenText("short", 10))
+	assert.Equal(t, "one two…", shortenText("one two three", 10))
+	assert.Equal(t, "one, two…", shortenText("one, two, three", 12))
+	assert.Equal(t, "abcdefghi…", shortenText("abcdefghijklmnop", 10))
+	assert.Equal(t, "ééééééééé…", shortenText(strings.Repeat("é", 20), 10))
+}
+
+// TestLargestSrcsetCandidate tests parsing srcset attributes
+func TestLargestSrcsetCandidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		srcset        string
+		expected      string
+		expectedWidth int
+	}{
+		{"empty", "", "", 0},
+		{"single", "/a.jpg", "/a.jpg", 0},
+		{"widths", "/a.jpg 300w, /b.jpg 900w, /c.jpg 600w", "/b.jpg", 900},
+		{"no spaces after commas", "/a.jpg 300w,/b.jpg 900w", "/b.jpg", 900},
+		{"commas in urls", "/w_300,h_200/a.jpg 300w, /w_900,h_600/a.jpg 900w", "/w_900,h_600/a.jpg", 900},
+		{"url ending with a comma", "/a.jpg, /b.jpg 900w", "/b.jpg", 900},
+		{"comma without whitespace is part of the url", "/a.jpg,/b.jpg 900w", "/a.jpg,/b.jpg", 900},
+		{"leading and trailing commas", " ,/a.jpg 300w, ", "/a.jpg", 300},
+		{"density descriptors", "/a.jpg 1x, /b.jpg 2x", "/a.jpg", 0},
+		{"parentheses in descriptors", "/a.jpg 300w (future, descriptor), /b.jpg 900w", "/b.jpg", 900},
+		{"newlines", "/a.jpg\n300w,\n/b.jpg\n900w", "/b.jpg", 900},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidate, width := largestSrcsetCandidate(test.srcset)
+			assert.Equal(t, test.expected, candidate)
+			assert.Equal(t, test.expectedWidth, width)
+		})
+	}
+}
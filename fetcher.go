@@ -0,0 +1,354 @@
+package metaextractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Fetcher defaults
+const (
+	DefaultFetchTimeout   = 20 * time.Second
+	DefaultMaxBodySize    = 2 << 20 // 2 MiB is plenty to reach the end of any <head>
+	DefaultMaxRetries     = 2
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 30 * time.Second
+	DefaultUserAgent      = "go-meta-extractor/1.0 (+https://github.com/mrz1836/go-meta-extractor)"
+)
+
+// Fetcher errors
+var (
+	ErrInvalidURL       = errors.New("invalid url: only absolute http and https urls are supported")
+	ErrTooManyRefreshes = errors.New("stopped after too many meta refresh redirects")
+	ErrUnexpectedStatus = errors.New("unexpected http status code")
+)
+
+// StatusError is returned when a page responds with a non-2xx status code
+//
+// It matches ErrUnexpectedStatus when used with errors.Is
+type StatusError struct {
+	RetryAfter time.Duration // Value of the Retry-After header (zero if missing)
+	StatusCode int
+	URL        string
+}
+
+// Error will return the error message
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d fetching %s", ErrUnexpectedStatus, e.StatusCode, e.URL)
+}
+
+// Unwrap will return ErrUnexpectedStatus
+func (e *StatusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// FetcherOptions are the options used when creating a new Fetcher
+type FetcherOptions struct {
+	Client         *http.Client   // HTTP client used for requests (defaults to a client with DefaultFetchTimeout)
+	Clock          Clock          // Source of time for rate limits and retries (defaults to the system clock)
+	CrawlDelay     time.Duration  // Minimum time between the start of two requests to the same host
+	HostBurst      int            // Number of requests a host's token bucket can hold
+	HostRate       float64        // Requests per second allowed per host (zero disables the token bucket)
+	MaxBodySize    int64          // Maximum number of body bytes to read from a page
+	MaxRefreshes   int            // Meta refresh redirects followed per page (zero does not follow them)
+	MaxRetries     int            // Retries for 429, 5xx and network errors (negative disables retries)
+	Random         func() float64 // Source of backoff jitter in [0.0, 1.0) (defaults to math/rand)
+	RespectRobots  bool           // Check robots.txt before fetching a page
+	RetryBaseDelay time.Duration  // Delay before the first retry, doubled on each attempt
+	RetryMaxDelay  time.Duration  // Maximum backoff delay and the longest Retry-After that will be waited on
+	RobotsCacheTTL time.Duration  // How long a host's robots.txt is cached
+	RobotsAgent    string         // User-agent matched against robots.txt groups (defaults to UserAgent)
+	UserAgent      string         // User-Agent header sent with every request
+}
+
+// DefaultFetcherOptions will return the default options for a Fetcher
+func DefaultFetcherOptions() *FetcherOptions {
+	return &FetcherOptions{
+		Client:         &http.Client{Timeout: DefaultFetchTimeout},
+		Clock:          systemClock{},
+		HostBurst:      1,
+		MaxBodySize:    DefaultMaxBodySize,
+		MaxRetries:     DefaultMaxRetries,
+		Random:         defaultRandom,
+		RetryBaseDelay: DefaultRetryBaseDelay,
+		RetryMaxDelay:  DefaultRetryMaxDelay,
+		RobotsCacheTTL: DefaultRobotsCacheTTL,
+		UserAgent:      DefaultUserAgent,
+	}
+}
+
+// Fetcher downloads pages over HTTP so their meta tags can be extracted
+//
+// Requests are rate limited per host and failed requests are retried with
+// exponential backoff. A Fetcher is safe for concurrent use by multiple goroutines
+type Fetcher struct {
+	backoff       backoff
+	client        *http.Client
+	clock         Clock
+	limiter       *hostLimiter
+	maxBodySize   int64
+	maxRefreshes  int
+	maxRetries    int
+	retryMaxDelay time.Duration
+	robots        *robotsCache // Nil unless robots.txt is respected
+	robotsAgent   string
+	userAgent     string
+}
+
+// NewFetcher will create a new Fetcher, any missing options are replaced with their defaults
+func NewFetcher(options *FetcherOptions) *Fetcher {
+	defaults := DefaultFetcherOptions()
+	if options == nil {
+		options = defaults
+	}
+
+	f := &Fetcher{
+		backoff: backoff{
+			base:   options.RetryBaseDelay,
+			max:    options.RetryMaxDelay,
+			random: options.Random,
+		},
+		client:        options.Client,
+		clock:         options.Clock,
+		maxBodySize:   options.MaxBodySize,
+		maxRefreshes:  max(options.MaxRefreshes, 0),
+		maxRetries:    options.MaxRetries,
+		retryMaxDelay: options.RetryMaxDelay,
+		robotsAgent:   options.RobotsAgent,
+		userAgent:     options.UserAgent,
+	}
+	if f.client == nil {
+		f.client = defaults.Client
+	}
+	if f.clock == nil {
+		f.clock = defaults.Clock
+	}
+	if f.maxBodySize <= 0 {
+		f.maxBodySize = defaults.MaxBodySize
+	}
+	if f.maxRetries == 0 {
+		f.maxRetries = defaults.MaxRetries
+	}
+	if f.backoff.base <= 0 {
+		f.backoff.base = defaults.RetryBaseDelay
+	}
+	if f.backoff.max <= 0 {
+		f.backoff.max = defaults.RetryMaxDelay
+		f.retryMaxDelay = defaults.RetryMaxDelay
+	}
+	if f.backoff.random == nil {
+		f.backoff.random = defaults.Random
+	}
+	if len(f.userAgent) == 0 {
+		f.userAgent = defaults.UserAgent
+	}
+	if len(f.robotsAgent) == 0 {
+		f.robotsAgent = f.userAgent
+	}
+	if options.RespectRobots {
+		ttl := options.RobotsCacheTTL
+		if ttl <= 0 {
+			ttl = defaults.RobotsCacheTTL
+		}
+		f.robots = newRobotsCache(ttl)
+	}
+	f.limiter = newHostLimiter(f.clock, options.HostRate, options.HostBurst, options.CrawlDelay)
+	return f
+}
+
+// Page is a page downloaded by the Fetcher
+type Page struct {
+	Body       []byte      // Body of the page, truncated at the fetcher's MaxBodySize
+	Header     http.Header // Response headers
+	StatusCode int         // Response status code
+	URL        string      // Final URL of the page after any redirects (including meta refresh ones)
+}
+
+// Fetch will download the page at rawURL
+//
+// When robots.txt is respected, a URL it disallows returns a *RobotsError
+// (matching ErrDisallowedByRobots) without being requested.
+// Requests wait for the host's rate limit and crawl delay before starting.
+// Responses with status 429 or 5xx and network errors are retried with
+// exponential backoff, waiting for the Retry-After header when one is sent.
+// Only 2xx responses are considered successful, anything else returns a
+// *StatusError (matching ErrUnexpectedStatus).
+// With MaxRefreshes, a page redirecting with <meta http-equiv="refresh"> is
+// replaced by the page it redirects to, returning ErrTooManyRefreshes when
+// the redirects go on for more than MaxRefreshes hops
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	page, err := f.fetch(ctx, rawURL)
+	for hops := 0; err == nil && f.maxRefreshes > 0; hops++ {
+		target := refreshTarget(page)
+		if len(target) == 0 {
+			break
+		}
+		if hops == f.maxRefreshes {
+			return nil, fmt.Errorf("%w: %d fetching %s", ErrTooManyRefreshes, hops, rawURL)
+		}
+		page, err = f.fetch(ctx, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// fetch downloads the page at rawURL, checking robots.txt, waiting for the
+// rate limit and retrying the failed requests
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := parseFetchURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := hostKey(u)
+
+	if f.robots != nil {
+		if err = f.checkRobots(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err = f.limiter.wait(ctx, host); err != nil {
+			return nil, err
+		}
+
+		var page *Page
+		if page, err = f.get(ctx, u); err == nil {
+			return page, nil
+		}
+		if attempt >= f.maxRetries || !isRetryable(ctx, err) {
+			return nil, err
+		}
+
+		delay := f.backoff.delay(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			// The server asked everyone to back off, not just this request
+			f.limiter.pause(host, f.clock.Now().Add(statusErr.RetryAfter))
+			if statusErr.RetryAfter > f.retryMaxDelay {
+				return nil, err
+			}
+			delay = statusErr.RetryAfter
+		}
+		if err = f.clock.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// get performs a single request for the page at u
+func (f *Fetcher) get(ctx context.Context, u *url.URL) (*Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+
+	var resp *http.Response
+	if resp, err = f.client.Do(req); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		statusErr := &StatusError{StatusCode: resp.StatusCode, URL: u.String()}
+		statusErr.RetryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"), f.clock.Now())
+		return nil, statusErr
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(resp.Body, f.maxBodySize)); err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		Body:       body,
+		Header:     resp.Header,
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.String(),
+	}, nil
+}
+
+// Extract will download the page at rawURL and extract its meta tags
+func (f *Fetcher) Extract(ctx context.Context, rawURL string) (Tags, error) {
+	page, err := f.Fetch(ctx, rawURL)
+	if err != nil {
+		return Tags{}, err
+	}
+	return Extract(bytes.NewReader(page.Body)), nil
+}
+
+// ExtractWithOptions will download the page at rawURL and extract its meta tags using options
+//
+// If options.URL is empty it is set to the final URL of the page, and an empty
+// options.Clock is set to the clock of the Fetcher
+func (f *Fetcher) ExtractWithOptions(ctx context.Context, rawURL string, options ExtractOptions) (*Result, error) {
+	page, err := f.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.URL) == 0 {
+		options.URL = page.URL
+	}
+	if options.Clock == nil {
+		options.Clock = f.clock
+	}
+	return ExtractWithOptions(bytes.NewReader(page.Body), options), nil
+}
+
+// refreshTarget returns the URL a page redirects to with a meta refresh, or an
+// empty string if it does not redirect to another http or https URL soon enough
+func refreshTarget(page *Page) string {
+	result := ExtractWithOptions(bytes.NewReader(page.Body), ExtractOptions{HTTPEquiv: true, URL: page.URL})
+	if result.HTTPEquiv == nil || result.HTTPEquiv.Refresh == nil {
+		return ""
+	}
+	refresh := result.HTTPEquiv.Refresh
+	if refresh.Delay > maxRefreshDelay || len(refresh.URL) == 0 || refresh.URL == page.URL {
+		return ""
+	}
+	if _, err := parseFetchURL(refresh.URL); err != nil {
+		return ""
+	}
+	return refresh.URL
+}
+
+// parseFetchURL parses and validates a URL that is about to be fetched
+func parseFetchURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidURL, rawURL)
+	}
+	return u, nil
+}
+
+// isRetryable returns true if a failed request is worth trying again
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNonPublicAddress) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests ||
+			statusErr.StatusCode == http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusBadGateway ||
+			statusErr.StatusCode == http.StatusServiceUnavailable ||
+			statusErr.StatusCode == http.StatusGatewayTimeout
+	}
+
+	// Anything else came from the transport (connection resets, timeouts, etc.)
+	return true
+}
@@ -0,0 +1,175 @@
+package metaextractor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPageServer will create a test server that serves the given HTML on every path
+func newTestPageServer(t *testing.T, page string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestNewFetcher will test the creation of a fetcher and its defaults
+func TestNewFetcher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil options use the defaults", func(t *testing.T) {
+		f := NewFetcher(nil)
+		require.NotNil(t, f)
+		assert.NotNil(t, f.client)
+		assert.Equal(t, int64(DefaultMaxBodySize), f.maxBodySize)
+		assert.Equal(t, DefaultUserAgent, f.userAgent)
+	})
+
+	t.Run("missing values use the defaults", func(t *testing.T) {
+		f := NewFetcher(&FetcherOptions{UserAgent: "custom-agent"})
+		require.NotNil(t, f)
+		assert.NotNil(t, f.client)
+		assert.Equal(t, int64(DefaultMaxBodySize), f.maxBodySize)
+		assert.Equal(t, "custom-agent", f.userAgent)
+	})
+}
+
+// TestFetcherFetch will test fetching a page
+func TestFetcherFetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("successful fetch", func(t *testing.T) {
+		var userAgent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.UserAgent()
+			_, _ = w.Write([]byte(`<html><head><title>` + testTitle + `</title></head></html>`))
+		}))
+		defer server.Close()
+
+		page, err := NewFetcher(nil).Fetch(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+		require.NotNil(t, page)
+		assert.Equal(t, http.StatusOK, page.StatusCode)
+		assert.Equal(t, server.URL+"/page", page.URL)
+		assert.Contains(t, string(page.Body), testTitle)
+		assert.Equal(t, DefaultUserAgent, userAgent)
+	})
+
+	t.Run("body is limited to max body size", func(t *testing.T) {
+		server := newTestPageServer(t, strings.Repeat("A", 100))
+		page, err := NewFetcher(&FetcherOptions{MaxBodySize: 10}).Fetch(context.Background(), server.URL)
+		require.NoError(t, err)
+		require.NotNil(t, page)
+		assert.Len(t, page.Body, 10)
+	})
+
+	t.Run("non 2xx status returns an error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		page, err := NewFetcher(nil).Fetch(context.Background(), server.URL)
+		require.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Nil(t, page)
+	})
+
+	t.Run("invalid urls return an error", func(t *testing.T) {
+		for _, rawURL := range []string{"", "ftp://example.com", "/relative/path", "http://", "://bad"} {
+			page, err := NewFetcher(nil).Fetch(context.Background(), rawURL)
+			require.ErrorIs(t, err, ErrInvalidURL, rawURL)
+			assert.Nil(t, page)
+		}
+	})
+}
+
+// TestFetcherExtract will test fetching and extracting a page
+func TestFetcherExtract(t *testing.T) {
+	t.Parallel()
+
+	server := newTestPageServer(t, `<html><head><title>`+testTitle+`</title><meta name="description" content="`+testDescription+`"></head></html>`)
+
+	tags, err := NewFetcher(nil).Extract(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, testTitle, tags.Title)
+	assert.Equal(t, testDescription, tags.Description)
+}
+
+// TestFetcherExtractWithOptions will test fetching and extracting a page with options
+func TestFetcherExtractWithOptions(t *testing.T) {
+	t.Parallel()
+
+	server := newTestPageServer(t, `<html><head><meta property="og:title" content="`+testTitle+`"></head></html>`)
+
+	result, err := NewFetcher(nil).ExtractWithOptions(context.Background(), server.URL, ExtractOptions{Provenance: true})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, testTitle, result.Title)
+	assert.Equal(t, TagOGTitle, result.Provenance[FieldTitle].Source)
+
+	_, err = NewFetcher(nil).ExtractWithOptions(context.Background(), "not a url", ExtractOptions{})
+	require.ErrorIs(t, err, ErrInvalidURL)
+}
+
+// TestFetcherFetchRefresh will test following meta refresh redirects
+func TestFetcherFetchRefresh(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/new"></head></html>`))
+		case "/new":
+			_, _ = w.Write([]byte(`<html><head><title>` + testTitle + `</title></head></html>`))
+		case "/slow":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="60; url=/new"></head></html>`))
+		case "/self":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0"></head></html>`))
+		case "/ftp":
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=ftp://example.com/"></head></html>`))
+		default:
+			_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=` + r.URL.Path + `x"></head></html>`))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("not followed by default", func(t *testing.T) {
+		page, err := NewFetcher(nil).Fetch(context.Background(), server.URL+"/old")
+		require.NoError(t, err)
+		assert.Equal(t, server.URL+"/old", page.URL)
+	})
+
+	t.Run("followed", func(t *testing.T) {
+		page, err := NewFetcher(&FetcherOptions{MaxRefreshes: 1}).Fetch(context.Background(), server.URL+"/old")
+		require.NoError(t, err)
+		assert.Equal(t, server.URL+"/new", page.URL)
+		assert.Contains(t, string(page.Body), testTitle)
+	})
+
+	t.Run("long delays, reloads and other schemes are not redirects", func(t *testing.T) {
+		for _, path := range []string{"/slow", "/self", "/ftp"} {
+			page, err := NewFetcher(&FetcherOptions{MaxRefreshes: 3}).Fetch(context.Background(), server.URL+path)
+			require.NoError(t, err, path)
+			assert.Equal(t, server.URL+path, page.URL)
+		}
+	})
+
+	t.Run("hop limit", func(t *testing.T) {
+		page, err := NewFetcher(&FetcherOptions{MaxRefreshes: 3}).Fetch(context.Background(), server.URL+"/loop")
+		require.ErrorIs(t, err, ErrTooManyRefreshes)
+		assert.Nil(t, page)
+	})
+
+	t.Run("extract uses the final page", func(t *testing.T) {
+		result, err := NewFetcher(&FetcherOptions{MaxRefreshes: 1}).ExtractWithOptions(context.Background(), server.URL+"/old", ExtractOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, testTitle, result.Title)
+	})
+}
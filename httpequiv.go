@@ -0,0 +1,154 @@
+package metaextractor
+
+import (
+	"math"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxRefreshDelay is the longest refresh delay, in seconds, that is treated
+// as a redirect by the Fetcher, longer ones are pages reloading themselves
+const maxRefreshDelay = 5
+
+// maxQuotedLength is the longest value quoted in a warning message, longer values are truncated
+const maxQuotedLength = 200
+
+// asciiSpace are the ASCII whitespace characters of the HTML specification
+const asciiSpace = "\t\n\f\r "
+
+// HTTPEquiv are the values of the <meta http-equiv> tags (and <meta charset>),
+// which stand in for the HTTP headers of the same name
+type HTTPEquiv struct {
+	Charset               string   `json:"charset,omitempty"`          // Lowercase, from <meta charset> or Content-Type
+	ContentLanguage       string   `json:"content_language,omitempty"` // As declared (see Tags.Language for the normalized one)
+	ContentSecurityPolicy string   `json:"content_security_policy,omitempty"`
+	ContentType           string   `json:"content_type,omitempty"`  // Lowercase media type, without its parameters
+	DefaultStyle          string   `json:"default_style,omitempty"` // Title of the preferred style sheet set
+	Refresh               *Refresh `json:"refresh,omitempty"`
+}
+
+// FieldHTTPEquiv starts the field names of the http-equiv values, which
+// follow their JSON paths in the Result (e.g. "http_equiv.content_type")
+const FieldHTTPEquiv = "http_equiv"
+
+// Refresh is a <meta http-equiv="refresh"> tag, which reloads the page or
+// redirects to another one after a delay
+type Refresh struct {
+	Delay int    `json:"delay"`         // Seconds before the refresh
+	URL   string `json:"url,omitempty"` // Target resolved against ExtractOptions.URL, empty when the page reloads itself
+}
+
+// addHTTPEquiv sets the value of a <meta http-equiv> or <meta charset> tag,
+// the first value of each wins
+func (e *extractor) addHTTPEquiv(t html.Token) {
+	name, content, charset := "", "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagHTTPEquiv:
+			name = strings.ToLower(strings.TrimSpace(attr.Val))
+		case TagContent:
+			content = strings.TrimSpace(attr.Val)
+		case TagCharset:
+			charset = strings.ToLower(strings.TrimSpace(attr.Val))
+		}
+	}
+	if len(charset) == 0 && (len(name) == 0 || len(content) == 0) {
+		return
+	}
+
+	h := e.result.HTTPEquiv
+	if h == nil {
+		h = &HTTPEquiv{}
+	}
+	if len(charset) > 0 {
+		e.setFirst(&h.Charset, FieldHTTPEquiv+".charset", TagCharset, charset)
+	}
+	switch name {
+	case TagContentLanguage:
+		e.setFirst(&h.ContentLanguage, FieldHTTPEquiv+".content_language", name, content)
+	case TagContentSecurityPolicy:
+		e.setFirst(&h.ContentSecurityPolicy, FieldHTTPEquiv+".content_security_policy", name, content)
+	case TagContentType:
+		if mediaType, params, err := mime.ParseMediaType(content); err == nil {
+			e.setFirst(&h.ContentType, FieldHTTPEquiv+".content_type", name, mediaType)
+			if charset = strings.ToLower(params["charset"]); len(charset) > 0 {
+				e.setFirst(&h.Charset, FieldHTTPEquiv+".charset", name, charset)
+			}
+		}
+	case TagDefaultStyle:
+		e.setFirst(&h.DefaultStyle, FieldHTTPEquiv+".default_style", name, content)
+	case TagRefresh:
+		if delay, target, ok := ParseRefresh(content); ok && h.Refresh == nil {
+			h.Refresh = &Refresh{Delay: delay}
+			e.set(&h.Refresh.URL, FieldHTTPEquiv+".refresh.url", name, resolveRefresh(e.options.URL, target))
+		} else if !ok && e.options.Warnings {
+			e.warn(WarningInvalidRefresh, "", TagRefresh, "refresh "+strconv.Quote(truncateField(content, maxQuotedLength))+" is not valid")
+		}
+	}
+	if *h != (HTTPEquiv{}) {
+		e.result.HTTPEquiv = h
+	}
+}
+
+// ParseRefresh will parse the content of a <meta http-equiv="refresh"> tag
+// such as "5" or "0; url='/new'" into its delay in seconds and its target
+// URL (empty when the page reloads itself), following the HTML specification
+func ParseRefresh(content string) (delay int, target string, ok bool) {
+	s := strings.TrimLeft(content, asciiSpace)
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	if digits == 0 && !strings.HasPrefix(s, ".") {
+		return 0, "", false
+	}
+	if digits > 0 {
+		var err error
+		if delay, err = strconv.Atoi(s[:digits]); err != nil {
+			delay = math.MaxInt32 // Too long to ever happen
+		}
+	}
+	s = strings.TrimLeft(s[digits:], "0123456789.")
+	if len(s) == 0 {
+		return delay, "", true
+	}
+
+	if !strings.ContainsRune(";,"+asciiSpace, rune(s[0])) {
+		return 0, "", false
+	}
+	s = strings.TrimLeft(s, asciiSpace)
+	if strings.HasPrefix(s, ";") || strings.HasPrefix(s, ",") {
+		s = strings.TrimLeft(s[1:], asciiSpace)
+	}
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		if rest := strings.TrimLeft(s[3:], asciiSpace); strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], asciiSpace)
+		}
+	}
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		quote := s[0]
+		s = s[1:]
+		if end := strings.IndexByte(s, quote); end >= 0 {
+			s = s[:end]
+		}
+	}
+	return delay, strings.TrimRight(s, asciiSpace), true
+}
+
+// resolveRefresh resolves a refresh target against the URL of the document,
+// returning it unchanged if either cannot be parsed
+func resolveRefresh(base, target string) string {
+	if len(target) == 0 || len(base) == 0 {
+		return target
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || !baseURL.IsAbs() {
+		return target
+	}
+	resolved, err := baseURL.Parse(target)
+	if err != nil {
+		return target
+	}
+	return resolved.String()
+}
@@ -0,0 +1,140 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseRefresh tests parsing the content of refresh tags
+func TestParseRefresh(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		content string
+		delay   int
+		target  string
+		ok      bool
+	}{
+		{"5", 5, "", true},
+		{" 30 ", 30, "", true},
+		{"0; url=/new", 0, "/new", true},
+		{"0;URL=https://example.com/new", 0, "https://example.com/new", true},
+		{"3, url = 'https://example.com/a b' ", 3, "https://example.com/a b", true},
+		{`0; url="/quoted"; extra`, 0, "/quoted", true},
+		{"0; url='/unterminated", 0, "/unterminated", true},
+		{"0 /no-url-keyword", 0, "/no-url-keyword", true},
+		{"0; urlpath", 0, "urlpath", true},
+		{"1.5; url=/fraction", 1, "/fraction", true},
+		{".5; url=/dot", 0, "/dot", true},
+		{"0;", 0, "", true},
+		{"99999999999999999999", 2147483647, "", true},
+		{"", 0, "", false},
+		{"url=/new", 0, "", false},
+		{"-1; url=/new", 0, "", false},
+		{"5x; url=/new", 0, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			delay, target, ok := ParseRefresh(test.content)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.delay, delay)
+			assert.Equal(t, test.target, target)
+		})
+	}
+}
+
+// TestExtractWithOptionsHTTPEquiv tests collecting the <meta http-equiv> values
+func TestExtractWithOptionsHTTPEquiv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		url      string
+		expected *HTTPEquiv
+	}{
+		{
+			name: "every value",
+			mockHTML: `<head><meta charset="UTF-8">
+				<meta http-equiv="Content-Type" content="text/html; charset=ISO-8859-1">
+				<meta http-equiv="content-language" content="en-us">
+				<meta http-equiv="Default-Style" content="Dark">
+				<meta http-equiv="Content-Security-Policy" content="default-src 'self'">
+				<meta http-equiv="refresh" content="0; url=../new?a=1">
+				<meta http-equiv="refresh" content="10"></head>`,
+			url: "https://example.com/old/page",
+			expected: &HTTPEquiv{
+				Charset:               "utf-8",
+				ContentLanguage:       "en-us",
+				ContentSecurityPolicy: "default-src 'self'",
+				ContentType:           "text/html",
+				DefaultStyle:          "Dark",
+				Refresh:               &Refresh{URL: "https://example.com/new?a=1"},
+			},
+		},
+		{
+			name:     "charset from content-type",
+			mockHTML: `<head><meta http-equiv="content-type" content="Text/HTML; Charset=Shift_JIS"></head>`,
+			expected: &HTTPEquiv{Charset: "shift_jis", ContentType: "text/html"},
+		},
+		{
+			name:     "refresh without a document url",
+			mockHTML: `<head><meta http-equiv="refresh" content="300"><meta http-equiv="refresh" content="0; url=/x"></head>`,
+			expected: &HTTPEquiv{Refresh: &Refresh{Delay: 300}},
+		},
+		{
+			name:     "relative refresh kept without a document url",
+			mockHTML: `<head><meta http-equiv="refresh" content="2; url=/next"></head>`,
+			expected: &HTTPEquiv{Refresh: &Refresh{Delay: 2, URL: "/next"}},
+		},
+		{
+			name: "nothing",
+			mockHTML: `<head><meta http-equiv="X-UA-Compatible" content="IE=edge">
+				<meta http-equiv="content-type" content=";;"><meta http-equiv="refresh" content="soon">
+				<meta name="description" content="Not http-equiv"></head>`,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{HTTPEquiv: true, URL: test.url})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.HTTPEquiv)
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta http-equiv="refresh" content="0; url=/x"></head>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.HTTPEquiv)
+	})
+
+	t.Run("values are truncated like the tags", func(t *testing.T) {
+		page := `<head><meta http-equiv="Content-Security-Policy" content="default-src ` + strings.Repeat("https://a.example.com ", 100000) + `">
+			<meta http-equiv="refresh" content="x` + strings.Repeat("y", 20000) + `"></head>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{HTTPEquiv: true, Provenance: true, Warnings: true})
+		require.NotNil(t, result)
+		require.NotNil(t, result.HTTPEquiv)
+		assert.Len(t, result.HTTPEquiv.ContentSecurityPolicy, MaxFieldLength)
+		assert.True(t, result.Provenance["http_equiv.content_security_policy"].Truncated)
+
+		require.Len(t, result.Warnings, 2)
+		assert.Equal(t, WarningTruncated, result.Warnings[0].Code)
+		assert.Equal(t, "http_equiv.content_security_policy", result.Warnings[0].Field)
+		assert.Equal(t, WarningInvalidRefresh, result.Warnings[1].Code)
+		assert.Less(t, len(result.Warnings[1].Message), 300)
+	})
+
+	t.Run("invalid refresh warning", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader("<head>\n<meta http-equiv=\"refresh\" content=\"soon\"></head>"),
+			ExtractOptions{HTTPEquiv: true, Warnings: true})
+		require.NotNil(t, result)
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningInvalidRefresh, result.Warnings[0].Code)
+		assert.Equal(t, TagRefresh, result.Warnings[0].Tag)
+		assert.Equal(t, 2, result.Warnings[0].Line)
+	})
+}
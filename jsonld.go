@@ -0,0 +1,133 @@
+package metaextractor
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// jsonLDSupportingTypes are the schema.org types that describe something around
+// the page (its publisher, breadcrumbs, etc.) rather than the page itself
+var jsonLDSupportingTypes = map[string]bool{
+	"BreadcrumbList":         true,
+	"Brand":                  true,
+	"Corporation":            true,
+	"ImageObject":            true,
+	"ListItem":               true,
+	"NewsMediaOrganization":  true,
+	"Organization":           true,
+	"Person":                 true,
+	"SearchAction":           true,
+	"SiteNavigationElement":  true,
+	"SpeakableSpecification": true,
+	"WPFooter":               true,
+	"WPHeader":               true,
+	"WPSideBar":              true,
+	"WebSite":                true,
+}
+
+// jsonLDNodes decodes JSON-LD blocks into a flat list of nodes, expanding
+// top-level arrays and @graph lists. Blocks that are not valid JSON are skipped.
+func jsonLDNodes(blocks []string) []map[string]any {
+	var nodes []map[string]any
+	var add func(v any)
+	add = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, item := range v {
+				add(item)
+			}
+		case map[string]any:
+			if graph, ok := v["@graph"]; ok {
+				add(graph)
+			}
+			if _, ok := v["@type"]; ok {
+				nodes = append(nodes, v)
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		var v any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(block)), &v); err == nil {
+			add(v)
+		}
+	}
+	return nodes
+}
+
+// jsonLDTypes returns the @type of a node, which may be a string or a list
+func jsonLDTypes(node map[string]any) []string {
+	switch v := node["@type"].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonLDHasType returns true if the node has any of the given types
+func jsonLDHasType(node map[string]any, types ...string) bool {
+	for _, nodeType := range jsonLDTypes(node) {
+		for _, t := range types {
+			if nodeType == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonLDIsMain returns true if the node describes the page itself
+func jsonLDIsMain(node map[string]any) bool {
+	for _, nodeType := range jsonLDTypes(node) {
+		if !jsonLDSupportingTypes[nodeType] {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLDString returns a property as text: strings are returned as is (with
+// HTML entities decoded), lists return their first value and objects return
+// their "name", "url" or "@value"
+func jsonLDString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return html.UnescapeString(v)
+	case []any:
+		for _, item := range v {
+			if s := jsonLDString(item); len(s) > 0 {
+				return s
+			}
+		}
+	case map[string]any:
+		for _, key := range []string{"name", "url", "@value"} {
+			if s := jsonLDString(v[key]); len(s) > 0 {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// jsonLDFirst returns the first non-empty text value of key across nodes,
+// only looking at the nodes accepted by match (all nodes if match is nil)
+func jsonLDFirst(nodes []map[string]any, key string, match func(map[string]any) bool) string {
+	for _, node := range nodes {
+		if match != nil && !match(node) {
+			continue
+		}
+		if s := jsonLDString(node[key]); len(s) > 0 {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package metaextractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJSONLDNodes tests decoding JSON-LD blocks into nodes
+func TestJSONLDNodes(t *testing.T) {
+	t.Parallel()
+
+	nodes := jsonLDNodes([]string{
+		`{"@context":"https://schema.org","@type":"NewsArticle","headline":"Story"}`,
+		`[{"@type":"Organization","name":"Example News"},{"name":"no type"}]`,
+		`{"@context":"https://schema.org","@graph":[{"@type":["WebPage","ItemPage"],"name":"Page"},{"@type":"WebSite","name":"Example"}]}`,
+		`not json`,
+		``,
+	})
+	require.Len(t, nodes, 4)
+	assert.Equal(t, []string{"NewsArticle"}, jsonLDTypes(nodes[0]))
+	assert.Equal(t, []string{"WebPage", "ItemPage"}, jsonLDTypes(nodes[2]))
+	assert.True(t, jsonLDHasType(nodes[3], "Organization", "WebSite"))
+	assert.False(t, jsonLDHasType(nodes[3], "Organization"))
+
+	assert.True(t, jsonLDIsMain(nodes[0]))
+	assert.False(t, jsonLDIsMain(nodes[1]))
+	assert.True(t, jsonLDIsMain(nodes[2]))
+	assert.False(t, jsonLDIsMain(map[string]any{}))
+
+	assert.Equal(t, "Story", jsonLDFirst(nodes, "headline", nil))
+	assert.Equal(t, "Example News", jsonLDFirst(nodes, "name", nil))
+	assert.Equal(t, "Page", jsonLDFirst(nodes, "name", jsonLDIsMain))
+	assert.Empty(t, jsonLDFirst(nodes, "missing", nil))
+}
+
+// TestJSONLDString tests reading JSON-LD properties as text
+func TestJSONLDString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"string", "Rock &amp; Roll", "Rock & Roll"},
+		{"list", []any{"", "second"}, "second"},
+		{"object name", map[string]any{"@type": "Organization", "name": "Example"}, "Example"},
+		{"object url", map[string]any{"url": "https://example.com/a.png"}, "https://example.com/a.png"},
+		{"object value", map[string]any{"@value": "text", "@language": "en"}, "text"},
+		{"number", 42.0, ""},
+		{"nil", nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, jsonLDString(test.value))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package metaextractor
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// KeywordSourceJSONLD is the source of the keywords of the main JSON-LD node,
+// the other sources are the meta names and properties (e.g. "news_keywords")
+const KeywordSourceJSONLD = "json-ld"
+
+// maxKeywordLength is the longest keyword kept, longer ones are usually sentences
+const maxKeywordLength = 100
+
+// keywordTrim are the characters trimmed from the ends of a keyword
+const keywordTrim = " \"'`“”‘’«»"
+
+// keywordMetaTags are the meta names and properties holding keywords
+var keywordMetaTags = map[string]bool{
+	TagArticleTag:   true,
+	TagKeywords:     true,
+	TagNewsKeywords: true,
+	TagParselyTags:  true,
+}
+
+// keyword is a keyword found in the document before they are de-duplicated
+type keyword struct {
+	source string
+	value  string
+}
+
+// addMetaKeywords adds the keywords of a meta tag, which may hold a
+// comma-separated list or be repeated (e.g. one article:tag per keyword)
+func (e *extractor) addMetaKeywords(t html.Token) {
+	source, content := "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName, TagProperty:
+			if key := strings.ToLower(attr.Val); keywordMetaTags[key] {
+				source = key
+			}
+		case TagContent:
+			content = attr.Val
+		}
+	}
+	if len(source) > 0 {
+		e.keywords = appendKeywords(e.keywords, source, content)
+	}
+}
+
+// jsonLDKeywords returns the keywords of the main JSON-LD nodes, which may be
+// a comma-separated string or a list
+func jsonLDKeywords(nodes []map[string]any) []keyword {
+	var keywords []keyword
+	for _, node := range nodes {
+		if jsonLDIsMain(node) {
+			for _, value := range jsonLDStrings(node["keywords"]) {
+				keywords = appendKeywords(keywords, KeywordSourceJSONLD, value)
+			}
+		}
+	}
+	return keywords
+}
+
+// appendKeywords splits a comma-separated list of keywords and appends them
+func appendKeywords(keywords []keyword, source, list string) []keyword {
+	for _, value := range strings.Split(list, ",") {
+		if value = cleanKeyword(value); len(value) > 0 {
+			keywords = append(keywords, keyword{source: source, value: value})
+		}
+	}
+	return keywords
+}
+
+// cleanKeyword collapses the whitespace of a keyword and trims the quotes
+// around it, returning an empty string if nothing useful is left
+func cleanKeyword(value string) string {
+	value = strings.Trim(NormalizeText(value), keywordTrim)
+	if len(value) > maxKeywordLength || !strings.ContainsFunc(value, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) {
+		return ""
+	}
+	return value
+}
+
+// pickKeywords de-duplicates the keywords of the meta tags and the JSON-LD,
+// ignoring case and keeping the spelling and order they were first found in
+func (e *extractor) pickKeywords() {
+	keywords := append(e.keywords, jsonLDKeywords(jsonLDNodes(e.jsonLD))...)
+	if len(keywords) == 0 {
+		return
+	}
+
+	first := make(map[string]string, len(keywords))
+	e.result.KeywordSources = make(map[string][]string)
+	for _, k := range keywords {
+		key := strings.ToLower(k.value)
+		value, found := first[key]
+		if !found {
+			value = k.value
+			first[key] = value
+			e.result.Keywords = append(e.result.Keywords, value)
+		}
+		if sources := e.result.KeywordSources[value]; !slices.Contains(sources, k.source) {
+			e.result.KeywordSources[value] = append(sources, k.source)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCleanKeyword tests normalizing a single keyword
+func TestCleanKeyword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"golang", "golang"},
+		{"  machine \n learning ", "machine learning"},
+		{`"quoted"`, "quoted"},
+		{"“smart quotes”", "smart quotes"},
+		{"C++", "C++"},
+		{"C#", "C#"},
+		{"2024", "2024"},
+		{"", ""},
+		{" - ", ""},
+		{strings.Repeat("a", maxKeywordLength+1), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, cleanKeyword(test.value))
+		})
+	}
+}
+
+// TestExtractWithOptionsKeywords tests collecting the keywords from every source
+func TestExtractWithOptionsKeywords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		keywords []string
+		sources  map[string][]string
+	}{
+		{
+			name:     "meta keywords",
+			mockHTML: `<head><meta name="keywords" content="go, html,  meta tags ,,"></head>`,
+			keywords: []string{"go", "html", "meta tags"},
+			sources: map[string][]string{
+				"go":        {TagKeywords},
+				"html":      {TagKeywords},
+				"meta tags": {TagKeywords},
+			},
+		},
+		{
+			name: "repeated tags and every source",
+			mockHTML: `<head><meta name="news_keywords" content="Elections, Senate">
+				<meta property="article:tag" content="senate">
+				<meta property="article:tag" content="Polling">
+				<meta name="parsely-tags" content="polling,Campaigns">
+				<meta name="Keywords" content="Elections">
+				<script type="application/ld+json">{"@type":"NewsArticle","keywords":["Campaigns","Budget, Taxes"]}</script></head>`,
+			keywords: []string{"Elections", "Senate", "Polling", "Campaigns", "Budget", "Taxes"},
+			sources: map[string][]string{
+				"Budget":    {KeywordSourceJSONLD},
+				"Campaigns": {TagParselyTags, KeywordSourceJSONLD},
+				"Elections": {TagNewsKeywords, TagKeywords},
+				"Polling":   {TagArticleTag, TagParselyTags},
+				"Senate":    {TagNewsKeywords, TagArticleTag},
+				"Taxes":     {KeywordSourceJSONLD},
+			},
+		},
+		{
+			name:     "json-ld string",
+			mockHTML: `<head><script type="application/ld+json">{"@graph":[{"@type":"BlogPosting","keywords":"recipes, bread"}]}</script></head>`,
+			keywords: []string{"recipes", "bread"},
+			sources: map[string][]string{
+				"bread":   {KeywordSourceJSONLD},
+				"recipes": {KeywordSourceJSONLD},
+			},
+		},
+		{
+			name:     "nothing",
+			mockHTML: `<head><meta name="keywords" content=" , "><meta name="description" content="a, b"></head>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{Keywords: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.keywords, result.Keywords)
+			assert.Equal(t, test.sources, result.KeywordSources)
+			assert.Nil(t, result.JSONLD)
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta name="keywords" content="go"></head>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Keywords)
+		assert.Nil(t, result.KeywordSources)
+	})
+}
@@ -0,0 +1,197 @@
+package metaextractor
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/language"
+)
+
+// LanguageDefault is the hreflang value of the alternate for users of any other language
+const LanguageDefault = "x-default"
+
+// minDetectLetters is the number of letters needed before DetectLanguage makes a guess
+const minDetectLetters = 20
+
+// languageVocabulary is the number of distinct trigrams assumed for smoothing unseen trigrams
+const languageVocabulary = 4000
+
+// NormalizeLanguage will return the BCP 47 form of a language tag, or locale
+// such as "en_US", and an empty string if it is not a valid tag
+//
+// Only the first value of a list (e.g. "de, en" or "fr;q=0.9") is used
+func NormalizeLanguage(value string) string {
+	value, _, _ = strings.Cut(value, ",")
+	value, _, _ = strings.Cut(value, ";")
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, LanguageDefault) {
+		return LanguageDefault
+	}
+
+	tag, err := language.Parse(value)
+	if err != nil || tag == language.Und {
+		return ""
+	}
+	return tag.String()
+}
+
+// scriptLanguages maps the scripts used by a single main language to it
+var scriptLanguages = []struct {
+	language string
+	script   *unicode.RangeTable
+}{
+	{"ar", unicode.Arabic},
+	{"el", unicode.Greek},
+	{"he", unicode.Hebrew},
+	{"hi", unicode.Devanagari},
+	{"hy", unicode.Armenian},
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ka", unicode.Georgian},
+	{"ko", unicode.Hangul},
+	{"ru", unicode.Cyrillic},
+	{"th", unicode.Thai},
+	{"zh", unicode.Han},
+}
+
+// languageSamples are the texts the trigram profiles of the languages written in
+// the Latin script are built from
+var languageSamples = map[string]string{
+	"de": `Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen. Jeder hat das Recht auf Leben, Freiheit und Sicherheit der Person. Hier finden Sie die neuesten Nachrichten aus Deutschland und der ganzen Welt, mit den Geschichten, die für Sie und Ihre Familie wichtig sind. Lesen Sie mehr darüber, wie wir daran arbeiten, die Dinge, die Sie lieben, noch besser zu machen, und erfahren Sie, was die Leute über die neuen Funktionen sagen, die in diesem Jahr verfügbar sein werden. Es gibt nichts, was wir nicht für unsere Leser tun würden.`,
+	"en": `All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood. Everyone has the right to life, liberty and security of person. Here you will find the latest news from the country and around the world, with the stories that matter to you and your family. Read more about how we are working to make the things you love even better, and find out what people are saying about the new features that will be available this year. There is nothing that we would not do for our readers.`,
+	"es": `Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros. Todo individuo tiene derecho a la vida, a la libertad y a la seguridad de su persona. Aquí encontrará las últimas noticias de España y del mundo, con las historias que importan para usted y su familia. Lea más sobre cómo trabajamos para que las cosas que le gustan sean todavía mejores, y descubra lo que la gente dice de las nuevas funciones que estarán disponibles este año. No hay nada que no haríamos por nuestros lectores.`,
+	"fr": `Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité. Tout individu a droit à la vie, à la liberté et à la sûreté de sa personne. Retrouvez les dernières nouvelles de France et du monde, avec les histoires qui comptent pour vous et votre famille. Découvrez comment nous travaillons pour rendre les choses que vous aimez encore meilleures, et ce que les gens disent des nouvelles fonctionnalités qui seront disponibles cette année. Il n'y a rien que nous ne ferions pas pour nos lecteurs.`,
+	"id": `Semua orang dilahirkan merdeka dan mempunyai martabat dan hak-hak yang sama. Mereka dikaruniai akal dan hati nurani dan hendaknya bergaul satu sama lain dalam semangat persaudaraan. Setiap orang berhak atas kehidupan, kebebasan dan keselamatan sebagai individu. Di sini Anda dapat menemukan berita terbaru dari Indonesia dan dunia, dengan cerita yang penting bagi Anda dan keluarga Anda. Baca lebih lanjut tentang bagaimana kami bekerja untuk membuat hal-hal yang Anda sukai menjadi lebih baik, dan cari tahu apa yang dikatakan orang tentang fitur baru yang akan tersedia tahun ini. Tidak ada yang tidak akan kami lakukan untuk para pembaca kami.`,
+	"it": `Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza. Ogni individuo ha diritto alla vita, alla libertà ed alla sicurezza della propria persona. Qui trovi le ultime notizie dall'Italia e dal mondo, con le storie che contano per te e per la tua famiglia. Scopri come lavoriamo per rendere ancora migliori le cose che ami, e che cosa dicono le persone delle nuove funzioni che saranno disponibili quest'anno. Non c'è niente che non faremmo per i nostri lettori.`,
+	"nl": `Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen. Een ieder heeft recht op leven, vrijheid en onschendbaarheid van zijn persoon. Hier vindt u het laatste nieuws uit Nederland en de rest van de wereld, met de verhalen die belangrijk zijn voor u en uw familie. Lees meer over hoe wij werken om de dingen waar u van houdt nog beter te maken, en ontdek wat mensen zeggen over de nieuwe functies die dit jaar beschikbaar komen. Er is niets dat wij niet voor onze lezers zouden doen.`,
+	"pl": `Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw. Są oni obdarzeni rozumem i sumieniem i powinni postępować wobec innych w duchu braterstwa. Każdy człowiek ma prawo do życia, wolności i bezpieczeństwa swojej osoby. Tutaj znajdziesz najnowsze wiadomości z Polski i ze świata, z historiami, które są ważne dla ciebie i twojej rodziny. Dowiedz się więcej o tym, jak pracujemy nad tym, aby rzeczy, które kochasz, były jeszcze lepsze, i sprawdź, co ludzie mówią o nowych funkcjach, które będą dostępne w tym roku. Nie ma niczego, czego nie zrobilibyśmy dla naszych czytelników.`,
+	"pt": `Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade. Todo o indivíduo tem direito à vida, à liberdade e à segurança pessoal. Aqui você encontra as últimas notícias do Brasil e do mundo, com as histórias que importam para você e sua família. Saiba mais sobre como trabalhamos para tornar ainda melhores as coisas que você ama, e descubra o que as pessoas estão dizendo sobre as novas funções que estarão disponíveis este ano. Não há nada que não faríamos pelos nossos leitores.`,
+	"sv": `Alla människor är födda fria och lika i värde och rättigheter. De är utrustade med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap. Var och en har rätt till liv, frihet och personlig säkerhet. Här hittar du de senaste nyheterna från Sverige och världen, med berättelserna som betyder något för dig och din familj. Läs mer om hur vi arbetar för att göra sakerna du älskar ännu bättre, och ta reda på vad folk säger om de nya funktionerna som blir tillgängliga i år. Det finns inget som vi inte skulle göra för våra läsare.`,
+	"tr": `Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler. Yaşamak, hürriyet ve kişi emniyeti her ferdin hakkıdır. Burada Türkiye'den ve dünyadan en son haberleri, sizin ve aileniz için önemli olan hikayelerle birlikte bulabilirsiniz. Sevdiğiniz şeyleri daha da iyi hale getirmek için nasıl çalıştığımızı okuyun ve insanların bu yıl kullanıma sunulacak yeni özellikler hakkında neler söylediğini öğrenin. Okuyucularımız için yapmayacağımız hiçbir şey yoktur.`,
+}
+
+// trigramProfile holds how often each trigram appears in the sample of a language
+type trigramProfile struct {
+	counts   map[string]int
+	language string
+	total    int
+}
+
+// languageProfiles are built from the languageSamples the first time they are needed
+var (
+	languageProfiles     []trigramProfile
+	languageProfilesOnce sync.Once
+)
+
+// DetectLanguage will guess the language of a text using trigram profiles built
+// into the library, returning a BCP 47 language (e.g. "fr") or an empty string
+// if the text is too short to tell
+//
+// Languages written in their own script (Greek, Japanese, Korean, etc.) are found
+// from the script alone, others from the Latin script are compared to the profiles
+// of de, en, es, fr, id, it, nl, pl, pt, sv and tr
+func DetectLanguage(text string) string {
+	scripts := make(map[string]int)
+	latin := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				scripts[s.language]++
+				break
+			}
+		}
+	}
+
+	// Japanese mixes kana with Han characters, so any kana decides it
+	if scripts["ja"] > 0 {
+		scripts["ja"] += scripts["zh"]
+		delete(scripts, "zh")
+	}
+	best, count := "", 0
+	for lang, n := range scripts {
+		if n > count || (n == count && lang < best) {
+			best, count = lang, n
+		}
+	}
+	if count > latin {
+		if count < minDetectLetters/4 {
+			return ""
+		}
+		return refineScriptLanguage(best, text)
+	}
+	if latin < minDetectLetters {
+		return ""
+	}
+	return detectLatinLanguage(text)
+}
+
+// refineScriptLanguage tells apart the languages sharing a script using the letters only one of them has
+func refineScriptLanguage(lang, text string) string {
+	switch {
+	case lang == "ru" && strings.ContainsAny(text, "іїєґІЇЄҐ"):
+		return "uk"
+	case lang == "ar" && strings.ContainsAny(text, "پچژگ"):
+		return "fa"
+	}
+	return lang
+}
+
+// detectLatinLanguage returns the language whose trigram profile is the most likely to produce text
+func detectLatinLanguage(text string) string {
+	languageProfilesOnce.Do(buildLanguageProfiles)
+
+	trigrams := textTrigrams(text)
+	best, bestScore := "", math.Inf(-1)
+	for _, profile := range languageProfiles {
+		var score float64
+		for trigram, n := range trigrams {
+			score += float64(n) * math.Log(float64(profile.counts[trigram]+1)/float64(profile.total+languageVocabulary))
+		}
+		if score > bestScore {
+			best, bestScore = profile.language, score
+		}
+	}
+	return best
+}
+
+// buildLanguageProfiles counts the trigrams of every language sample
+func buildLanguageProfiles() {
+	languages := make([]string, 0, len(languageSamples))
+	for lang := range languageSamples {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+
+	for _, lang := range languages {
+		profile := trigramProfile{counts: textTrigrams(languageSamples[lang]), language: lang}
+		for _, n := range profile.counts {
+			profile.total += n
+		}
+		languageProfiles = append(languageProfiles, profile)
+	}
+}
+
+// textTrigrams counts the trigrams of the lowercase words of text, padded with a space on each side
+func textTrigrams(text string) map[string]int {
+	trigrams := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			trigrams[string(runes[i:i+3])]++
+		}
+	}
+	return trigrams
+}
@@ -0,0 +1,184 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeLanguage tests converting language tags and locales to BCP 47
+func TestNormalizeLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"en", "en"},
+		{"en_US", "en-US"},
+		{"EN-us", "en-US"},
+		{" pt_br ", "pt-BR"},
+		{"zh-hant-tw", "zh-Hant-TW"},
+		{"sr-latn-rs", "sr-Latn-RS"},
+		{"iw", "he"},
+		{"de, en", "de"},
+		{"fr-FR;q=0.9", "fr-FR"},
+		{"X-Default", LanguageDefault},
+		{"", ""},
+		{"und", ""},
+		{"english", ""},
+		{"en US", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, NormalizeLanguage(test.value))
+		})
+	}
+}
+
+// TestDetectLanguage tests guessing the language of titles and descriptions
+func TestDetectLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"english", "How to bake sourdough bread at home. A step by step guide for beginners, with tips on flour, water and timing.", "en"},
+		{"german", "Wie man zu Hause Sauerteigbrot backt. Eine Schritt-für-Schritt-Anleitung für Anfänger mit Tipps zu Mehl und Wasser.", "de"},
+		{"spanish", "Cómo hacer pan de masa madre en casa. Una guía paso a paso para principiantes, con consejos sobre la harina y el agua.", "es"},
+		{"french", "Comment faire du pain au levain à la maison. Un guide étape par étape pour les débutants, avec des conseils sur la farine.", "fr"},
+		{"italian", "Come fare il pane con il lievito madre a casa. Una guida passo dopo passo per i principianti, con consigli sulla farina.", "it"},
+		{"portuguese", "Como fazer pão de fermentação natural em casa. Um guia passo a passo para iniciantes, com dicas sobre a farinha e a água.", "pt"},
+		{"dutch", "Hoe je thuis zuurdesembrood bakt. Een stapsgewijze handleiding voor beginners, met tips over bloem, water en de juiste tijd.", "nl"},
+		{"swedish", "Hur man bakar surdegsbröd hemma. En steg för steg guide för nybörjare, med tips om mjöl, vatten och tider.", "sv"},
+		{"polish", "Jak upiec chleb na zakwasie w domu. Przewodnik krok po kroku dla początkujących, z poradami dotyczącymi mąki i wody.", "pl"},
+		{"turkish", "Evde ekşi mayalı ekmek nasıl yapılır. Yeni başlayanlar için un, su ve zamanlama hakkında ipuçları içeren adım adım rehber.", "tr"},
+		{"indonesian", "Cara membuat roti dengan ragi alami di rumah. Panduan langkah demi langkah untuk pemula, dengan tips tentang tepung dan air.", "id"},
+		{"russian", "Как испечь хлеб на закваске дома", "ru"},
+		{"ukrainian", "Як спекти хліб на заквасці вдома", "uk"},
+		{"greek", "Πώς να φτιάξετε ψωμί με προζύμι", "el"},
+		{"japanese", "自宅でサワードウブレッドを焼く方法", "ja"},
+		{"chinese", "如何在家烤制酸面包", "zh"},
+		{"korean", "집에서 사워도우 빵을 굽는 방법", "ko"},
+		{"arabic", "كيفية خبز خبز العجين المخمر في المنزل", "ar"},
+		{"mostly latin with a brand name", "The best 東京 restaurants according to our readers and critics this year", "en"},
+		{"too short", "Home page", ""},
+		{"empty", "", ""},
+		{"no letters", "2024 - 12:30 / #1", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, DetectLanguage(test.text))
+		})
+	}
+}
+
+// TestExtractLanguage tests extracting the declared language, locale and hreflang alternates
+func TestExtractLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		language string
+		locale   string
+	}{
+		{"html lang", `<html lang="en-us"><head><title>T</title></head></html>`, "en-US", ""},
+		{"xml:lang", `<html xml:lang="fr"><head></head></html>`, "fr", ""},
+		{"lang wins over xml:lang", `<html xml:lang="fr" lang="de"><head></head></html>`, "de", ""},
+		{"content-language", `<head><meta http-equiv="Content-Language" content="de-DE, en"></head>`, "de-DE", ""},
+		{"og:locale", `<head><meta property="og:locale" content="pt_BR"></head>`, "pt-BR", "pt-BR"},
+		{"html lang wins", `<html lang="es"><head><meta http-equiv="content-language" content="en"><meta property="og:locale" content="fr_FR"></head></html>`, "es", "fr-FR"},
+		{"invalid html lang falls back", `<html lang="english"><head><meta property="og:locale" content="en_GB"></head></html>`, "en-GB", "en-GB"},
+		{"invalid og:locale is kept as is", `<head><meta property="og:locale" content="en_XYZ1"></head>`, "", "en_XYZ1"},
+		{"nothing declared", `<html><head><title>T</title></head></html>`, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tags := Extract(strings.NewReader(test.mockHTML))
+			assert.Equal(t, test.language, tags.Language)
+			assert.Equal(t, test.locale, tags.OGLocale)
+		})
+	}
+
+	page := `<html lang="en"><head>
+		<link rel="alternate" hreflang="de_DE" href="https://example.com/de/">
+		<link rel="alternate" hreflang="x-default" href="https://example.com/">
+		<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+		<link rel="canonical" hreflang="en" href="https://example.com/en/">
+		<link rel="alternate" hreflang="klingon!" href="https://example.com/tlh/">
+	</head></html>`
+
+	t.Run("alternates", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Alternates: true})
+		require.NotNil(t, result)
+		assert.Equal(t, []Alternate{
+			{Href: "https://example.com/de/", Language: "de-DE"},
+			{Href: "https://example.com/", Language: LanguageDefault},
+		}, result.Alternates)
+		assert.Nil(t, result.Links)
+	})
+
+	t.Run("hreflang of links", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Links: true})
+		require.NotNil(t, result)
+		require.Len(t, result.Links, 5)
+		assert.Equal(t, "de-DE", result.Links[0].Hreflang)
+		assert.Empty(t, result.Links[2].Hreflang)
+		assert.Nil(t, result.Alternates)
+	})
+
+	t.Run("provenance and warnings", func(t *testing.T) {
+		doc := "<html lang=\"nope!\">\n<head>\n<meta property=\"og:locale\" content=\"it_IT\">\n</head></html>"
+		result := ExtractWithOptions(strings.NewReader(doc), ExtractOptions{Provenance: true, Warnings: true})
+		require.NotNil(t, result)
+		assert.Equal(t, "it-IT", result.Language)
+		assert.Equal(t, Provenance{Line: 3, Offset: 27, Source: TagOGLocale}, result.Provenance[FieldLanguage])
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningInvalidLanguage, result.Warnings[0].Code)
+		assert.Equal(t, FieldLanguage, result.Warnings[0].Field)
+		assert.Equal(t, TagHTML, result.Warnings[0].Tag)
+		assert.Equal(t, 1, result.Warnings[0].Line)
+	})
+}
+
+// TestExtractWithOptionsDetectLanguage tests detecting the language next to the declared one
+func TestExtractWithOptionsDetectLanguage(t *testing.T) {
+	t.Parallel()
+
+	undeclared := `<head><title>Comment faire du pain au levain</title>
+		<meta name="description" content="Un guide étape par étape pour les débutants, avec des conseils sur la farine et l'eau."></head>`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(undeclared), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Empty(t, result.DetectedLanguage)
+	})
+
+	t.Run("detected when nothing is declared", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(undeclared), ExtractOptions{DetectLanguage: true})
+		require.NotNil(t, result)
+		assert.Empty(t, result.Language)
+		assert.Equal(t, "fr", result.DetectedLanguage)
+	})
+
+	t.Run("detected when declared", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<html lang="fr-CA">`+undeclared+`</html>`), ExtractOptions{DetectLanguage: true})
+		require.NotNil(t, result)
+		assert.Equal(t, "fr-CA", result.Language)
+		assert.Equal(t, "fr", result.DetectedLanguage)
+	})
+
+	t.Run("mismatch between declared and detected", func(t *testing.T) {
+		mismatch := `<html lang="en"><head><title>Wie man Sauerteigbrot zu Hause backt</title>
+			<meta name="description" content="Eine Schritt-für-Schritt-Anleitung für Anfänger, mit Tipps zu Mehl, Wasser und der richtigen Temperatur."></head></html>`
+		result := ExtractWithOptions(strings.NewReader(mismatch), ExtractOptions{DetectLanguage: true})
+		require.NotNil(t, result)
+		assert.Equal(t, "en", result.Language)
+		assert.Equal(t, "de", result.DetectedLanguage)
+	})
+}
@@ -0,0 +1,226 @@
+package metaextractor
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// metaField is a meta name or property extracted into the Tags
+type metaField struct {
+	apply func(e *extractor, tags *Tags, value string)
+	tag   string
+}
+
+// metaFields are the meta names and properties extracted into the Tags, in
+// the order they are applied when a tag has both a name and a property
+var metaFields = []metaField{
+	{tag: TagMetaDescription, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.Description, FieldDescription, TagMetaDescription, value)
+	}},
+	{tag: TagMetaAuthor, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.Author, FieldAuthor, TagMetaAuthor, value)
+	}},
+	{tag: TagOGTitle, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGTitle, FieldOGTitle, TagOGTitle, value)
+		if len(tags.Title) == 0 {
+			e.set(&tags.Title, FieldTitle, TagOGTitle, value)
+		}
+	}},
+	{tag: TagOGDescription, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGDescription, FieldOGDescription, TagOGDescription, value)
+		if len(tags.Description) == 0 {
+			e.set(&tags.Description, FieldDescription, TagOGDescription, value)
+		}
+	}},
+	{tag: TagOGImage, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGImage, FieldOGImage, TagOGImage, value)
+	}},
+	{tag: TagOGImageWidth, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGImageWidth, FieldOGImageWidth, TagOGImageWidth, value)
+	}},
+	{tag: TagOGImageHeight, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGImageHeight, FieldOGImageHeight, TagOGImageHeight, value)
+	}},
+	// The declared language comes from <html lang> first, then Content-Language and og:locale
+	{tag: TagOGLocale, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGLocale, FieldOGLocale, TagOGLocale, normalizeLocale(value))
+		e.setLanguage(TagOGLocale, value)
+	}},
+	{tag: TagOGSiteName, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGSiteName, FieldOGSiteName, TagOGSiteName, value)
+	}},
+	{tag: TagOGType, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGType, FieldOGType, TagOGType, value)
+	}},
+	{tag: TagOGURL, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGURL, FieldOGURL, TagOGURL, value)
+	}},
+	// og:video:url and og:video:secure_url are only used if og:video is not found
+	{tag: TagOGVideo, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGVideo, FieldOGVideo, TagOGVideo, value)
+	}},
+	{tag: TagOGVideoURL, apply: func(e *extractor, tags *Tags, value string) {
+		if len(tags.OGVideo) == 0 {
+			e.set(&tags.OGVideo, FieldOGVideo, TagOGVideoURL, value)
+		}
+	}},
+	{tag: TagOGVideoSecureURL, apply: func(e *extractor, tags *Tags, value string) {
+		if len(tags.OGVideo) == 0 {
+			e.set(&tags.OGVideo, FieldOGVideo, TagOGVideoSecureURL, value)
+		}
+	}},
+	{tag: TagOGVideoType, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGVideoType, FieldOGVideoType, TagOGVideoType, value)
+	}},
+	{tag: TagOGVideoWidth, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGVideoWidth, FieldOGVideoWidth, TagOGVideoWidth, value)
+	}},
+	{tag: TagOGVideoHeight, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGVideoHeight, FieldOGVideoHeight, TagOGVideoHeight, value)
+	}},
+	{tag: TagOGPublisher, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGPublisher, FieldOGPublisher, TagOGPublisher, value)
+	}},
+	{tag: TagOGAuthor, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.OGAuthor, FieldOGAuthor, TagOGAuthor, value)
+		if len(tags.Author) == 0 {
+			e.set(&tags.Author, FieldAuthor, TagOGAuthor, value)
+		}
+	}},
+	// Twitter card (use if OG not found)
+	{tag: TagTwitterTitle, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterTitle, FieldTwitterTitle, TagTwitterTitle, value)
+		if len(tags.Title) == 0 {
+			e.set(&tags.Title, FieldTitle, TagTwitterTitle, value)
+		}
+	}},
+	{tag: TagTwitterDescription, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterDescription, FieldTwitterDescription, TagTwitterDescription, value)
+		if len(tags.Description) == 0 {
+			e.set(&tags.Description, FieldDescription, TagTwitterDescription, value)
+		}
+	}},
+	{tag: TagTwitterImage, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterImage, FieldTwitterImage, TagTwitterImage, value)
+		if len(tags.OGImage) == 0 {
+			e.set(&tags.OGImage, FieldOGImage, TagTwitterImage, value)
+		}
+	}},
+	{tag: TagTwitterCard, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterCard, FieldTwitterCard, TagTwitterCard, value)
+	}},
+	{tag: TagTwitterPlayer, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterPlayer, FieldTwitterPlayer, TagTwitterPlayer, value)
+	}},
+	{tag: TagTwitterPlayerWidth, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterPlayerWidth, FieldTwitterPlayerWidth, TagTwitterPlayerWidth, value)
+	}},
+	{tag: TagTwitterPlayerHeight, apply: func(e *extractor, tags *Tags, value string) {
+		e.set(&tags.TwitterPlayerHeight, FieldTwitterPlayerHeight, TagTwitterPlayerHeight, value)
+	}},
+	// Pages may have one theme-color per media query, the first is the default
+	{tag: TagThemeColor, apply: func(e *extractor, tags *Tags, value string) {
+		if len(tags.ThemeColor) == 0 {
+			e.set(&tags.ThemeColor, FieldThemeColor, TagThemeColor, value)
+		}
+	}},
+}
+
+// metaFieldIndex maps the meta names and properties to their index in metaFields
+var metaFieldIndex = func() map[string]int {
+	index := make(map[string]int, len(metaFields))
+	for i, field := range metaFields {
+		index[field.tag] = i
+	}
+	return index
+}()
+
+// contentLanguage is TagContentLanguage, compared to the http-equiv attributes without allocating
+var contentLanguage = []byte(TagContentLanguage)
+
+// metaTag holds what the Tags need from the attributes of a <meta>, read in a single pass
+type metaTag struct {
+	content         string
+	contentLanguage bool   // http-equiv is Content-Language
+	fields          [2]int // Indexes in metaFields of the name and property (-1 when unknown)
+}
+
+// newMetaTag reads a <meta> tag that was already tokenized
+func newMetaTag(t html.Token) metaTag {
+	m := metaTag{fields: [2]int{-1, -1}}
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName, TagProperty:
+			if i, ok := metaFieldIndex[attr.Val]; ok {
+				m.addField(i)
+			}
+		case TagContent:
+			m.content = attr.Val
+		case TagHTTPEquiv:
+			m.contentLanguage = strings.EqualFold(strings.TrimSpace(attr.Val), TagContentLanguage)
+		}
+	}
+	return m
+}
+
+// readMeta reads the attributes of the current <meta> tag straight from the
+// tokenizer and applies it, only copying the content when the tag is extracted
+func (e *extractor) readMeta(z *html.Tokenizer, hasAttr bool) {
+	m := metaTag{fields: [2]int{-1, -1}}
+	var content []byte
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		switch string(key) {
+		case TagName, TagProperty:
+			if i, ok := metaFieldIndex[string(val)]; ok {
+				m.addField(i)
+			}
+		case TagContent:
+			content = val
+		case TagHTTPEquiv:
+			m.contentLanguage = bytes.EqualFold(bytes.TrimSpace(val), contentLanguage)
+		}
+	}
+	if m.fields[0] >= 0 || m.contentLanguage {
+		m.content = string(content)
+		e.applyMeta(&m)
+	}
+}
+
+// addField records a known name or property, keeping the fields in table order
+func (m *metaTag) addField(i int) {
+	switch {
+	case m.fields[0] < 0:
+		m.fields[0] = i
+	case m.fields[0] == i || m.fields[1] == i:
+	case i < m.fields[0]:
+		m.fields[0], m.fields[1] = i, m.fields[0]
+	default:
+		m.fields[1] = i
+	}
+}
+
+// applyMeta sets the Tags from a <meta> tag
+func (e *extractor) applyMeta(m *metaTag) {
+	if m.contentLanguage {
+		e.setLanguage(TagContentLanguage, m.content)
+	}
+	for _, i := range m.fields {
+		if i >= 0 {
+			metaFields[i].apply(e, &e.result.Tags, m.content)
+			e.declareOG(metaFields[i].tag, m.content)
+		}
+	}
+}
+
+// declareOG records an Open Graph property declared with a value, so Validate
+// can tell it apart from a field filled in from another tag
+func (e *extractor) declareOG(tag, value string) {
+	if strings.HasPrefix(tag, "og:") && len(strings.TrimSpace(value)) > 0 && !slices.Contains(e.result.OGDeclared, tag) {
+		e.result.OGDeclared = append(e.result.OGDeclared, tag)
+	}
+}
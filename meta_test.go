@@ -0,0 +1,81 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetaFieldIndex tests that every meta field is indexed once
+func TestMetaFieldIndex(t *testing.T) {
+	t.Parallel()
+
+	require.Len(t, metaFieldIndex, len(metaFields))
+	for i, field := range metaFields {
+		assert.Equal(t, i, metaFieldIndex[field.tag], field.tag)
+	}
+}
+
+// TestExtractMetaTags tests that meta tags are read the same way straight from
+// the tokenizer and from the whole token (when an option needs it)
+func TestExtractMetaTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected Tags
+	}{
+		{
+			name:     "name and property are both applied in order",
+			mockHTML: `<head><meta name="description" property="og:description" content="Both"></head>`,
+			expected: Tags{Description: "Both", OGDescription: "Both"},
+		},
+		{
+			name:     "property before name",
+			mockHTML: `<head><meta property="og:title" name="twitter:title" content="Shared"></head>`,
+			expected: Tags{Title: "Shared", OGTitle: "Shared", TwitterTitle: "Shared"},
+		},
+		{
+			name:     "names are case-sensitive",
+			mockHTML: `<head><meta name="Description" content="Ignored"><meta PROPERTY="og:type" content="article"></head>`,
+			expected: Tags{OGType: "article"},
+		},
+		{
+			name:     "duplicate attributes keep the first",
+			mockHTML: `<head><meta content="First" name="author" content="Second"></head>`,
+			expected: Tags{Author: "First"},
+		},
+		{
+			name:     "entities are decoded",
+			mockHTML: `<head><meta name="description" content="Fish &amp; Chips"></head>`,
+			expected: Tags{Description: "Fish & Chips"},
+		},
+		{
+			name:     "content language",
+			mockHTML: `<head><meta http-equiv=" Content-Language " content="fr-ca"><meta property="og:locale" content="en_US"></head>`,
+			expected: Tags{Language: "fr-CA", OGLocale: "en-US"},
+		},
+		{
+			name:     "video fallbacks and first theme color",
+			mockHTML: `<head><meta property="og:video:url" content="a.mp4"><meta property="og:video:secure_url" content="b.mp4"><meta name="theme-color" content="#fff"><meta name="theme-color" content="#000"></head>`,
+			expected: Tags{OGVideo: "a.mp4", ThemeColor: "#fff"},
+		},
+		{
+			name:     "unknown tags and attributes",
+			mockHTML: `<head><meta name="robots" content="noindex"><meta itemprop="name" content="Nope"><meta name="description"></head>`,
+			expected: Tags{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Extract(strings.NewReader(test.mockHTML)))
+
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{Warnings: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.Tags)
+		})
+	}
+}
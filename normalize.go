@@ -0,0 +1,101 @@
+package metaextractor
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Characters that are invisible but still meaningful, so they survive NormalizeText
+const (
+	zeroWidthJoiner    = '\u200d' // Joins emoji sequences (e.g. the "technologist" emoji)
+	zeroWidthNonJoiner = '\u200c' // Required by Persian and other scripts
+	tagCharactersStart = '\U000e0020'
+	tagCharactersEnd   = '\U000e007f' // Tag characters make up subdivision flags (e.g. the flag of Scotland)
+)
+
+// NormalizeText will clean up text extracted from a document: runs of
+// whitespace (including tabs, newlines and NBSPs) are collapsed to a single
+// space, the result is trimmed, invisible and control characters (zero-width
+// spaces, bidi controls, soft hyphens, etc.) are removed and Unicode NFC is applied
+//
+// Zero-width joiners and non-joiners are kept since emoji and some scripts need them
+func NormalizeText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		case isInvisible(r):
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// StripTags will remove any HTML tags from s, along with the contents of
+// <script> and <style> elements, and decode the HTML entities left in the text.
+// Tags are replaced by a space and runs of whitespace are collapsed, while s is
+// returned unchanged if it has no tags.
+//
+// It is meant for content attributes that contain markup, like
+// content="<p>A <b>great</b> read</p>"
+func StripTags(s string) string {
+	if !strings.ContainsRune(s, '<') {
+		return s
+	}
+
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return cleanText(b.String())
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken:
+			if name, _ := z.TagName(); isRawTextTag(name) {
+				skip++
+			}
+			b.WriteByte(' ')
+		case html.EndTagToken:
+			if name, _ := z.TagName(); isRawTextTag(name) {
+				skip = max(skip-1, 0)
+			}
+			b.WriteByte(' ')
+		case html.SelfClosingTagToken:
+			b.WriteByte(' ')
+		case html.CommentToken, html.DoctypeToken:
+			continue
+		}
+	}
+}
+
+// isRawTextTag returns true for the elements whose text is never displayed
+func isRawTextTag(name []byte) bool {
+	return string(name) == TagScript || string(name) == "style"
+}
+
+// isInvisible returns true for control and formatting characters that are not displayed
+func isInvisible(r rune) bool {
+	if unicode.Is(unicode.Cc, r) {
+		return true
+	}
+	if r == zeroWidthJoiner || r == zeroWidthNonJoiner || (r >= tagCharactersStart && r <= tagCharactersEnd) {
+		return false
+	}
+	return unicode.Is(unicode.Cf, r)
+}
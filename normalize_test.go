@@ -0,0 +1,105 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeText tests cleaning up whitespace and invisible characters
+func TestNormalizeText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"clean", "Test Title", "Test Title"},
+		{"newlines and tabs", "\n\tTest\n\t\tTitle\r\n", "Test Title"},
+		{"runs of spaces", "Test     Title", "Test Title"},
+		{"nbsp", "Test\u00a0\u00a0Title\u00a0", "Test Title"},
+		{"unicode spaces", "Test\u2003Title\u3000", "Test Title"},
+		{"zero-width space", "Test\u200bTitle", "TestTitle"},
+		{"zero-width space between spaces", "Test \u200b Title", "Test Title"},
+		{"byte order mark", "\ufeffTest Title", "Test Title"},
+		{"bidi controls", "\u202eTest\u202c \u200fTitle\u2066", "Test Title"},
+		{"soft hyphen", "Extra\u00adordinary", "Extraordinary"},
+		{"control characters", "Test\x00\x07Title\x7f", "TestTitle"},
+		{"zero-width joiner is kept", "\U0001f468\u200d\U0001f4bb", "\U0001f468\u200d\U0001f4bb"},
+		{"zero-width non-joiner is kept", "\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645", "\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645"},
+		{"nfc", "Cafe\u0301", "Caf\u00e9"},
+		{"invalid utf-8", "Test\xffTitle", "Test\ufffdTitle"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NormalizeText(test.input))
+		})
+	}
+}
+
+// TestStripTags tests removing HTML tags from values
+func TestStripTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no tags", "  A  great read ", "  A  great read "},
+		{"less than", "1 < 2", "1 < 2"},
+		{"inline tags", "<p>A <b>great</b> read</p>", "A great read"},
+		{"block tags", "<p>One</p><p>Two</p>", "One Two"},
+		{"line breaks", "One<br/>Two<br>Three", "One Two Three"},
+		{"entities", "<i>Rock &amp; Roll</i>", "Rock & Roll"},
+		{"script and style", "<style>p{}</style>Text<script>alert(1)</script>", "Text"},
+		{"comments", "A<!-- hidden -->B", "AB"},
+		{"unclosed tag", "Text <b", "Text"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, StripTags(test.input))
+		})
+	}
+}
+
+// TestExtractWithOptionsNormalize tests normalizing every field during extraction
+func TestExtractWithOptionsNormalize(t *testing.T) {
+	t.Parallel()
+
+	page := "<head><title>\n\t Test\u00a0Title\u200b\n</title>" +
+		`<meta name="description" content="&lt;p&gt;A &lt;b&gt;great&lt;/b&gt;` + "\n" + `read&lt;/p&gt;">` +
+		`<meta property="og:title" content="Cafe` + "\u0301" + `">` +
+		`<meta name="author" content="` + "\u200b\u00a0" + `">` +
+		`<meta property="og:author" content="` + testAuthor + `">` +
+		"</head>"
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Equal(t, "\n\t Test\u00a0Title\u200b\n", result.Title)
+		assert.Equal(t, "<p>A <b>great</b>\nread</p>", result.Description)
+		assert.Equal(t, "\u200b\u00a0", result.Author)
+	})
+
+	t.Run("normalize", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Normalize: true})
+		require.NotNil(t, result)
+		assert.Equal(t, "Test Title", result.Title)
+		assert.Equal(t, "<p>A <b>great</b> read</p>", result.Description)
+		assert.Equal(t, "Caf\u00e9", result.OGTitle)
+		assert.Equal(t, testAuthor, result.Author, "blank values fall back to the next tag")
+	})
+
+	t.Run("normalize and strip tags", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Normalize: true, StripTags: true})
+		require.NotNil(t, result)
+		assert.Equal(t, "A great read", result.Description)
+	})
+}
@@ -0,0 +1,144 @@
+package metaextractor
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxLabelIndex is the highest twitter:labelN and twitter:dataN index read
+const maxLabelIndex = 10
+
+// PlatformTags are the tags read by a single platform besides the Open Graph
+// and Twitter ones (Pinterest, LinkedIn, VK and Slack)
+type PlatformTags struct {
+	Labels               []LabelData `json:"labels,omitempty"`             // twitter:labelN and twitter:dataN pairs shown by Slack, by index
+	LinkedInImage        string      `json:"linkedin_image,omitempty"`     // <meta name="image">
+	NoPin                bool        `json:"no_pin,omitempty"`             // Pinterest saves are disabled (<meta name="pinterest" content="nopin">)
+	NoPinDescription     string      `json:"no_pin_description,omitempty"` // Message shown by Pinterest when saves are disabled
+	NoRichPin            bool        `json:"no_rich_pin,omitempty"`        // Pinterest rich pins are disabled (pinterest-rich-pin is "false")
+	PinterestDescription string      `json:"pinterest_description,omitempty"`
+	VKImage              string      `json:"vk_image,omitempty"`
+}
+
+// Field names of the platform tags, matching their JSON paths in the Result
+const (
+	FieldPlatformsLabels               = "platforms.labels"
+	FieldPlatformsLinkedInImage        = "platforms.linkedin_image"
+	FieldPlatformsNoPinDescription     = "platforms.no_pin_description"
+	FieldPlatformsPinterestDescription = "platforms.pinterest_description"
+	FieldPlatformsVKImage              = "platforms.vk_image"
+)
+
+// LabelData is a label and value pair (e.g. "Reading time" and "5 minutes")
+type LabelData struct {
+	Data  string `json:"data"`
+	Label string `json:"label"`
+}
+
+// platformState holds the platform tags while the document is read
+type platformState struct {
+	data   map[int]string // twitter:dataN by index
+	labels map[int]string // twitter:labelN by index
+	tags   PlatformTags
+}
+
+// addMetaPlatform adds a platform-specific meta tag, whose name and property
+// are read separately (LinkedIn uses name="image" next to property="og:image")
+func (e *extractor) addMetaPlatform(t html.Token) {
+	name, property, content, description := "", "", "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName:
+			name = strings.ToLower(attr.Val)
+		case TagProperty:
+			property = strings.ToLower(attr.Val)
+		case TagContent:
+			content = strings.TrimSpace(attr.Val)
+		case TagMetaDescription:
+			description = strings.TrimSpace(attr.Val)
+		}
+	}
+
+	e.addPlatform(name, content, description)
+	if property != name {
+		e.addPlatform(property, content, description)
+	}
+}
+
+// addPlatform sets the platform tag named key
+func (e *extractor) addPlatform(key, content, description string) {
+	p := &e.platform
+	switch key {
+	case TagLinkedInImage:
+		e.setFirst(&p.tags.LinkedInImage, FieldPlatformsLinkedInImage, key, content)
+	case TagNoPin:
+		p.tags.NoPin = true
+		e.setFirst(&p.tags.NoPinDescription, FieldPlatformsNoPinDescription, key, description)
+	case TagPinterest:
+		if strings.EqualFold(content, TagNoPin) {
+			p.tags.NoPin = true
+			e.setFirst(&p.tags.NoPinDescription, FieldPlatformsNoPinDescription, key, description)
+		}
+	case TagPinterestDescription:
+		e.setFirst(&p.tags.PinterestDescription, FieldPlatformsPinterestDescription, key, content)
+	case TagPinterestRichPin:
+		p.tags.NoRichPin = strings.EqualFold(content, "false") || content == "0"
+	case TagVKImage:
+		e.setFirst(&p.tags.VKImage, FieldPlatformsVKImage, key, content)
+	default:
+		if index, ok := labelIndex(key, TagTwitterLabel); ok {
+			p.labels = e.setIndexed(p.labels, index, FieldPlatformsLabels, key, content)
+		} else if index, ok = labelIndex(key, TagTwitterData); ok {
+			p.data = e.setIndexed(p.data, index, FieldPlatformsLabels, key, content)
+		}
+	}
+}
+
+// pickPlatforms pairs the labels with their data and sets the platform tags
+// on the result if any were found
+func (e *extractor) pickPlatforms() {
+	p := &e.platform
+	for index := 1; index <= maxLabelIndex; index++ {
+		if label, data := p.labels[index], p.data[index]; len(label) > 0 && len(data) > 0 {
+			p.tags.Labels = append(p.tags.Labels, LabelData{Data: data, Label: label})
+		}
+	}
+
+	if !p.tags.isEmpty() {
+		e.result.Platforms = &p.tags
+	}
+}
+
+// isEmpty returns true if no platform tag was found
+func (p *PlatformTags) isEmpty() bool {
+	return len(p.Labels) == 0 && len(p.LinkedInImage) == 0 && !p.NoPin && len(p.NoPinDescription) == 0 &&
+		!p.NoRichPin && len(p.PinterestDescription) == 0 && len(p.VKImage) == 0
+}
+
+// labelIndex returns the index of a twitter:labelN or twitter:dataN key
+func labelIndex(key, prefix string) (int, bool) {
+	suffix, found := strings.CutPrefix(key, prefix)
+	if !found {
+		return 0, false
+	}
+	index, err := strconv.Atoi(suffix)
+	if err != nil || index < 1 || index > maxLabelIndex {
+		return 0, false
+	}
+	return index, true
+}
+
+// setIndexed sets the value of an index the first time it is found, see set
+func (e *extractor) setIndexed(values map[int]string, index int, field, source, value string) map[int]string {
+	if values == nil {
+		values = make(map[int]string)
+	}
+	if _, found := values[index]; !found {
+		var dst string
+		e.set(&dst, field, source, value)
+		values[index] = dst
+	}
+	return values
+}
@@ -0,0 +1,141 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLabelIndex tests reading the index of the twitter:labelN and twitter:dataN tags
+func TestLabelIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key      string
+		index    int
+		expected bool
+	}{
+		{"twitter:label1", 1, true},
+		{"twitter:label10", 10, true},
+		{"twitter:label", 0, false},
+		{"twitter:label0", 0, false},
+		{"twitter:label11", 0, false},
+		{"twitter:label-1", 0, false},
+		{"twitter:labelx", 0, false},
+		{"twitter:data1", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			index, ok := labelIndex(test.key, TagTwitterLabel)
+			assert.Equal(t, test.expected, ok)
+			assert.Equal(t, test.index, index)
+		})
+	}
+}
+
+// TestExtractWithOptionsPlatforms tests collecting the platform-specific tags
+func TestExtractWithOptionsPlatforms(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected *PlatformTags
+	}{
+		{
+			name: "every platform",
+			mockHTML: `<head>
+				<meta name="pinterest-rich-pin" content="false">
+				<meta name="pinterest:description" content="Pin this recipe">
+				<meta name="pinterest" content="nopin" description="Sorry, you can't save from this site">
+				<meta name="image" content="https://example.com/linkedin.jpg">
+				<meta property="vk:image" content="https://example.com/vk.jpg">
+				<meta name="twitter:label1" content="Written by">
+				<meta name="twitter:data1" content="Jane Doe">
+				<meta name="twitter:label2" content="Reading time">
+				<meta name="twitter:data2" content="5 minutes">
+			</head>`,
+			expected: &PlatformTags{
+				Labels: []LabelData{
+					{Data: "Jane Doe", Label: "Written by"},
+					{Data: "5 minutes", Label: "Reading time"},
+				},
+				LinkedInImage:        "https://example.com/linkedin.jpg",
+				NoPin:                true,
+				NoPinDescription:     "Sorry, you can't save from this site",
+				NoRichPin:            true,
+				PinterestDescription: "Pin this recipe",
+				VKImage:              "https://example.com/vk.jpg",
+			},
+		},
+		{
+			name: "labels are paired by index",
+			mockHTML: `<head><meta name="twitter:data2" content="$10">
+				<meta name="twitter:label2" content="Price">
+				<meta name="twitter:label1" content="Orphan">
+				<meta name="twitter:data3" content="Orphan">
+				<meta name="twitter:label2" content="Ignored"></head>`,
+			expected: &PlatformTags{Labels: []LabelData{{Data: "$10", Label: "Price"}}},
+		},
+		{
+			name:     "linkedin image before og:image",
+			mockHTML: `<head><meta name="image" property="og:image" content="https://example.com/share.jpg"></head>`,
+			expected: &PlatformTags{LinkedInImage: "https://example.com/share.jpg"},
+		},
+		{
+			name:     "linkedin image after og:image",
+			mockHTML: `<head><meta property="og:image" name="image" content="https://example.com/share.jpg"></head>`,
+			expected: &PlatformTags{LinkedInImage: "https://example.com/share.jpg"},
+		},
+		{
+			name:     "nopin name",
+			mockHTML: `<head><meta name="nopin" content="nopin"></head>`,
+			expected: &PlatformTags{NoPin: true},
+		},
+		{
+			name: "nothing",
+			mockHTML: `<head><meta name="pinterest-rich-pin" content="true">
+				<meta name="pinterest" content="pin"><meta name="twitter:label1" content="Alone"></head>`,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{Platforms: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.Platforms)
+		})
+	}
+
+	t.Run("values are cleaned up and truncated like the tags", func(t *testing.T) {
+		page := `<head>
+			<meta name="twitter:label1" content="` + strings.Repeat("a", 20000) + `">
+			<meta name="twitter:data1" content="  Jane <b>Doe</b>  ">
+			<meta name="pinterest:description" content="Pin  this
+				recipe">
+			<meta name="image" content="https://example.com/linkedin.jpg"></head>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{
+			Normalize: true, Platforms: true, Provenance: true, StripTags: true, Warnings: true,
+		})
+		require.NotNil(t, result)
+		require.NotNil(t, result.Platforms)
+		require.Len(t, result.Platforms.Labels, 1)
+		assert.Len(t, result.Platforms.Labels[0].Label, MaxFieldLength)
+		assert.Equal(t, "Jane Doe", result.Platforms.Labels[0].Data)
+		assert.Equal(t, "Pin this recipe", result.Platforms.PinterestDescription)
+
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningTruncated, result.Warnings[0].Code)
+		assert.Equal(t, FieldPlatformsLabels, result.Warnings[0].Field)
+		assert.Equal(t, "twitter:label1", result.Warnings[0].Tag)
+		assert.Equal(t, Provenance{Line: 6, Offset: strings.Index(page, `<meta name="image"`), Source: TagLinkedInImage}, result.Provenance[FieldPlatformsLinkedInImage])
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta property="vk:image" content="a.jpg"></head>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Platforms)
+	})
+}
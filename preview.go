@@ -0,0 +1,336 @@
+package metaextractor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Preview is a link preview ("unfurl") built from the metadata of a page,
+// with every value picked from the best available source
+type Preview struct {
+	CanonicalURL string        `json:"canonical_url,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Favicon      string        `json:"favicon,omitempty"`
+	Heuristic    []string      `json:"heuristic,omitempty"` // "description" and "image" when they were derived from the body content
+	Image        *PreviewImage `json:"image,omitempty"`
+	Media        *PreviewMedia `json:"media,omitempty"`
+	SiteName     string        `json:"site_name,omitempty"`
+	ThemeColor   string        `json:"theme_color,omitempty"`
+	Title        string        `json:"title,omitempty"`
+}
+
+// PreviewImage is the image of a Preview, the dimensions are zero when unknown
+type PreviewImage struct {
+	Height int    `json:"height,omitempty"`
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+}
+
+// PreviewMedia is an embeddable video or player, the dimensions are zero when unknown
+type PreviewMedia struct {
+	Height int    `json:"height,omitempty"`
+	Type   string `json:"type,omitempty"` // MIME type, "text/html" for players that are embedded in an iframe
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+}
+
+// Preview media types
+const (
+	MediaTypePlayer = "text/html"
+)
+
+// ExtractPreview will extract the metadata of a document and build its Preview
+//
+// pageURL is the address of the document, used to resolve relative URLs and as
+// the last resort for the canonical URL and site name (it may be empty)
+func ExtractPreview(resp io.Reader, pageURL string) *Preview {
+	return BuildPreview(ExtractWithOptions(resp, ExtractOptions{Fallbacks: true, JSONLD: true, Links: true}), pageURL)
+}
+
+// Preview will download the page at rawURL and build its Preview
+func (f *Fetcher) Preview(ctx context.Context, rawURL string) (*Preview, error) {
+	page, err := f.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractPreview(bytes.NewReader(page.Body), page.URL), nil
+}
+
+// BuildPreview will build a Preview from an extraction result
+//
+// The result should have been extracted with the Fallbacks, JSONLD and Links
+// options, otherwise only the meta tags are used. Each value is the first one found:
+//
+//   - Title: og:title, twitter:title, JSON-LD headline or name, <title>.
+//     The site name is then removed when it is a prefix or suffix of the
+//     title (e.g. "Story | Example News" becomes "Story")
+//   - Description: og:description, twitter:description, JSON-LD description,
+//     meta description, the first paragraph of the body
+//   - Site name: og:site_name, JSON-LD WebSite name or publisher, the host of
+//     the page without "www."
+//   - Canonical URL: <link rel="canonical">, og:url, pageURL
+//   - Image: og:image (with og:image:width and og:image:height), twitter:image,
+//     JSON-LD image, <link rel="image_src">, the first large image of the body
+//   - Media: og:video (with its type and dimensions), twitter:player
+//   - Favicon: the largest <link rel="icon"> or apple-touch-icon, /favicon.ico
+//   - Theme colour: the first theme-color meta tag
+//
+// All text is trimmed with runs of whitespace collapsed to a single space and
+// all URLs are resolved against pageURL (or the canonical URL if pageURL is empty)
+func BuildPreview(result *Result, pageURL string) *Preview {
+	if result == nil {
+		result = &Result{}
+	}
+	nodes := jsonLDNodes(result.JSONLD)
+	p := &Preview{}
+
+	// Canonical URL first, it is the base for relative URLs when pageURL is missing
+	p.CanonicalURL = firstText(findLink(result.Links, "canonical"), result.OGURL)
+	base, _ := url.Parse(strings.TrimSpace(pageURL))
+	if base == nil || !base.IsAbs() {
+		base, _ = url.Parse(p.CanonicalURL)
+	}
+	if base != nil && !base.IsAbs() {
+		base = nil
+	}
+	p.CanonicalURL = resolveURL(base, firstText(p.CanonicalURL, pageURL))
+
+	host := ""
+	if base != nil {
+		host = strings.TrimPrefix(strings.ToLower(base.Hostname()), "www.")
+	}
+
+	p.SiteName = firstText(
+		result.OGSiteName,
+		jsonLDFirst(nodes, "name", func(node map[string]any) bool { return jsonLDHasType(node, "WebSite") }),
+		jsonLDFirst(nodes, "publisher", nil),
+		host,
+	)
+
+	p.Title = CleanTitle(firstText(
+		result.OGTitle,
+		result.TwitterTitle,
+		jsonLDFirst(nodes, "headline", nil),
+		jsonLDFirst(nodes, "name", jsonLDIsMain),
+		result.Title,
+	), p.SiteName, host)
+
+	p.Description = firstText(
+		result.OGDescription,
+		result.TwitterDescription,
+		jsonLDFirst(nodes, "description", jsonLDIsMain),
+	)
+	if len(p.Description) == 0 {
+		if p.Description = cleanText(result.Description); len(p.Description) > 0 && slices.Contains(result.Heuristic, FieldDescription) {
+			p.Heuristic = append(p.Heuristic, "description")
+		}
+	}
+
+	var heuristicImage bool
+	if p.Image, heuristicImage = previewImage(result, nodes, base); heuristicImage {
+		p.Heuristic = append(p.Heuristic, "image")
+	}
+	p.Media = previewMedia(result, base)
+	p.Favicon = previewFavicon(result.Links, base)
+	p.ThemeColor = cleanText(result.ThemeColor)
+
+	return p
+}
+
+// previewImage picks the image of the preview, reporting whether it was derived from the body content
+func previewImage(result *Result, nodes []map[string]any, base *url.URL) (*PreviewImage, bool) {
+	ogImage := &PreviewImage{
+		Height: parseDimension(result.OGImageHeight),
+		URL:    resolveURL(base, cleanText(result.OGImage)),
+		Width:  parseDimension(result.OGImageWidth),
+	}
+	heuristic := slices.Contains(result.Heuristic, FieldOGImage)
+	if len(ogImage.URL) > 0 && !heuristic {
+		return ogImage, false
+	}
+	if image := cleanText(result.TwitterImage); len(image) > 0 {
+		return &PreviewImage{URL: resolveURL(base, image)}, false
+	}
+	for _, node := range nodes {
+		if image := jsonLDImage(node["image"]); image != nil {
+			image.URL = resolveURL(base, image.URL)
+			return image, false
+		}
+	}
+	if image := findLink(result.Links, "image_src"); len(image) > 0 {
+		return &PreviewImage{URL: resolveURL(base, image)}, false
+	}
+	if len(ogImage.URL) > 0 {
+		return ogImage, true
+	}
+	return nil, false
+}
+
+// jsonLDImage returns the image described by a JSON-LD image property, which
+// may be a URL, an ImageObject or a list of either
+func jsonLDImage(v any) *PreviewImage {
+	switch v := v.(type) {
+	case string:
+		if image := cleanText(v); len(image) > 0 {
+			return &PreviewImage{URL: image}
+		}
+	case []any:
+		for _, item := range v {
+			if image := jsonLDImage(item); image != nil {
+				return image
+			}
+		}
+	case map[string]any:
+		if image := cleanText(firstText(jsonLDString(v["url"]), jsonLDString(v["contentUrl"]))); len(image) > 0 {
+			return &PreviewImage{
+				Height: parseDimension(jsonLDDimension(v["height"])),
+				URL:    image,
+				Width:  parseDimension(jsonLDDimension(v["width"])),
+			}
+		}
+	}
+	return nil
+}
+
+// jsonLDDimension returns a JSON-LD width or height as text, which may be a
+// number, a string or a QuantitativeValue
+func jsonLDDimension(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		return jsonLDDimension(v["value"])
+	}
+	return jsonLDString(v)
+}
+
+// previewMedia picks the embeddable media of the preview
+func previewMedia(result *Result, base *url.URL) *PreviewMedia {
+	if video := cleanText(result.OGVideo); len(video) > 0 {
+		return &PreviewMedia{
+			Height: parseDimension(result.OGVideoHeight),
+			Type:   cleanText(result.OGVideoType),
+			URL:    resolveURL(base, video),
+			Width:  parseDimension(result.OGVideoWidth),
+		}
+	}
+	if player := cleanText(result.TwitterPlayer); len(player) > 0 {
+		return &PreviewMedia{
+			Height: parseDimension(result.TwitterPlayerHeight),
+			Type:   MediaTypePlayer,
+			URL:    resolveURL(base, player),
+			Width:  parseDimension(result.TwitterPlayerWidth),
+		}
+	}
+	return nil
+}
+
+// previewFavicon picks the largest icon, falling back to /favicon.ico
+func previewFavicon(links []Link, base *url.URL) string {
+	best, bestSize := "", 0
+	for _, link := range links {
+		size := iconSize(link)
+		if size > bestSize {
+			best, bestSize = link.Href, size
+		}
+	}
+	if len(best) > 0 {
+		return resolveURL(base, best)
+	}
+	if base != nil {
+		return resolveURL(base, "/favicon.ico")
+	}
+	return ""
+}
+
+// iconSize returns the size in pixels of an icon link (zero if it is not an icon)
+//
+// Icons without a sizes attribute are assumed to be 16px, except for apple-touch-icons
+// which are 180px and SVG icons which scale to any size
+func iconSize(link Link) int {
+	size := 0
+	for _, rel := range strings.Fields(link.Rel) {
+		switch rel {
+		case "icon":
+			size = max(size, 16)
+		case "apple-touch-icon", "apple-touch-icon-precomposed":
+			size = max(size, 180)
+		}
+	}
+	if size == 0 {
+		return 0
+	}
+
+	if strings.EqualFold(link.Type, "image/svg+xml") {
+		return 1024
+	}
+	declared := 0
+	for _, s := range strings.Fields(strings.ToLower(link.Sizes)) {
+		if s == "any" {
+			return 1024
+		}
+		width, _, _ := strings.Cut(s, "x")
+		if n, err := strconv.Atoi(width); err == nil && n > declared {
+			declared = n
+		}
+	}
+	if declared > 0 {
+		return declared
+	}
+	return size
+}
+
+// findLink returns the href of the first link with the given rel
+func findLink(links []Link, rel string) string {
+	for _, link := range links {
+		for _, r := range strings.Fields(link.Rel) {
+			if r == rel {
+				return link.Href
+			}
+		}
+	}
+	return ""
+}
+
+// cleanText trims s and collapses runs of whitespace to a single space
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// firstText returns the first value that is not blank, cleaned with cleanText
+func firstText(values ...string) string {
+	for _, value := range values {
+		if value = cleanText(value); len(value) > 0 {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseDimension parses a width or height in pixels (zero if it is not a positive number)
+func parseDimension(s string) int {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "px")
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 || n > 1<<20 {
+		return 0
+	}
+	return int(n)
+}
+
+// resolveURL resolves ref against base, returning ref unchanged if either cannot be used
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || len(ref) == 0 {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
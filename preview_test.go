@@ -0,0 +1,212 @@
+package metaextractor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractPreview tests building previews from documents
+func TestExtractPreview(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		pageURL  string
+		expected *Preview
+	}{
+		{
+			name: "open graph",
+			mockHTML: `<html><head>
+				<title>Ignored | Example News</title>
+				<meta property="og:title" content="  Big   Story
+					Today | Example News ">
+				<meta property="og:description" content="What happened">
+				<meta property="og:site_name" content="Example News">
+				<meta property="og:image" content="/images/story.jpg">
+				<meta property="og:image:width" content="1200">
+				<meta property="og:image:height" content="630px">
+				<meta property="og:video" content="https://cdn.example.com/story.mp4">
+				<meta property="og:video:type" content="video/mp4">
+				<meta property="og:url" content="https://example.com/og-url">
+				<meta name="theme-color" content="#123456">
+				<link rel="canonical" href="/story">
+				<link rel="icon" href="/favicon-16.png" sizes="16x16">
+				<link rel="icon" href="/favicon-64.png" sizes="64x64">
+				<link rel="apple-touch-icon" href="/apple.png">
+			</head></html>`,
+			pageURL: "https://www.example.com/story?utm_source=chat",
+			expected: &Preview{
+				CanonicalURL: "https://www.example.com/story",
+				Description:  "What happened",
+				Favicon:      "https://www.example.com/apple.png",
+				Image:        &PreviewImage{Height: 630, URL: "https://www.example.com/images/story.jpg", Width: 1200},
+				Media:        &PreviewMedia{Type: "video/mp4", URL: "https://cdn.example.com/story.mp4"},
+				SiteName:     "Example News",
+				ThemeColor:   "#123456",
+				Title:        "Big Story Today",
+			},
+		},
+		{
+			name: "json-ld",
+			mockHTML: `<html><head>
+				<title>Example News - Fallback Title</title>
+				<meta name="description" content="Meta description">
+				<script type="application/ld+json">{
+					"@context": "https://schema.org",
+					"@graph": [
+						{"@type": "WebSite", "name": "Example News"},
+						{"@type": "Organization", "name": "Example Corp", "description": "Not this"},
+						{"@type": "NewsArticle", "headline": "Story &amp; More", "description": "Article description",
+						 "image": {"@type": "ImageObject", "url": "https://example.com/a.png", "width": 800, "height": {"value": "600"}}}
+					]
+				}</script>
+				<link rel="icon" href="/icon.svg" type="image/svg+xml">
+				<link rel="icon" href="/favicon-64.png" sizes="64x64">
+			</head></html>`,
+			pageURL: "https://example.com/story",
+			expected: &Preview{
+				CanonicalURL: "https://example.com/story",
+				Description:  "Article description",
+				Favicon:      "https://example.com/icon.svg",
+				Image:        &PreviewImage{Height: 600, URL: "https://example.com/a.png", Width: 800},
+				SiteName:     "Example News",
+				Title:        "Story & More",
+			},
+		},
+		{
+			name: "title tag and host fallbacks",
+			mockHTML: `<html><head>
+				<title>Example.com :: Page Title</title>
+				<meta name="description" content="Meta description">
+				<meta name="twitter:image" content="https://example.com/twitter.png">
+				<meta name="twitter:player" content="https://example.com/embed">
+				<meta name="twitter:player:width" content="480">
+				<meta name="twitter:player:height" content="nope">
+			</head></html>`,
+			pageURL: "https://www.example.com/page",
+			expected: &Preview{
+				CanonicalURL: "https://www.example.com/page",
+				Description:  "Meta description",
+				Favicon:      "https://www.example.com/favicon.ico",
+				Image:        &PreviewImage{URL: "https://example.com/twitter.png"},
+				Media:        &PreviewMedia{Type: MediaTypePlayer, URL: "https://example.com/embed", Width: 480},
+				SiteName:     "example.com",
+				Title:        "Page Title",
+			},
+		},
+		{
+			name:     "relative urls are resolved against the canonical url without a page url",
+			mockHTML: `<head><title>Only - A Title</title><link rel="canonical" href="https://example.com/a/"><link rel="image_src" href="img.png"></head>`,
+			expected: &Preview{
+				CanonicalURL: "https://example.com/a/",
+				Favicon:      "https://example.com/favicon.ico",
+				Image:        &PreviewImage{URL: "https://example.com/a/img.png"},
+				SiteName:     "example.com",
+				Title:        "Only - A Title",
+			},
+		},
+		{
+			name:     "nothing to resolve against",
+			mockHTML: `<head><title> Just a title </title><meta property="og:image" content="/a.png"></head>`,
+			expected: &Preview{
+				Image: &PreviewImage{URL: "/a.png"},
+				Title: "Just a title",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ExtractPreview(strings.NewReader(test.mockHTML), test.pageURL))
+		})
+	}
+}
+
+// TestBuildPreview tests building a preview from results without links or JSON-LD
+func TestBuildPreview(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, &Preview{}, BuildPreview(nil, ""))
+
+	result := ExtractWithOptions(strings.NewReader(`<head><title>Title</title><link rel="icon" href="/icon.png"></head>`), ExtractOptions{})
+	assert.Equal(t, &Preview{
+		CanonicalURL: "https://example.com",
+		Favicon:      "https://example.com/favicon.ico",
+		SiteName:     "example.com",
+		Title:        "Title",
+	}, BuildPreview(result, "https://example.com"))
+}
+
+// TestIconSize tests ranking icon links
+func TestIconSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		link     Link
+		expected int
+	}{
+		{Link{Rel: "stylesheet"}, 0},
+		{Link{Rel: "icon"}, 16},
+		{Link{Rel: "shortcut icon", Sizes: "16x16 48X48"}, 48},
+		{Link{Rel: "apple-touch-icon"}, 180},
+		{Link{Rel: "apple-touch-icon", Sizes: "152x152"}, 152},
+		{Link{Rel: "icon", Sizes: "any"}, 1024},
+		{Link{Rel: "icon", Type: "image/svg+xml"}, 1024},
+		{Link{Rel: "icon", Sizes: "big"}, 16},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, iconSize(test.link), test.link)
+	}
+}
+
+// TestFetcherPreview tests building a preview from a downloaded page
+func TestFetcherPreview(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		_, _ = w.Write([]byte(`<head><title>Story | Site</title><meta property="og:site_name" content="Site"><meta property="og:image" content="/a.png"></head>`))
+	}))
+	defer server.Close()
+
+	preview, err := NewFetcher(nil).Preview(context.Background(), server.URL+"/old")
+	require.NoError(t, err)
+	assert.Equal(t, "Story", preview.Title)
+	assert.Equal(t, server.URL+"/new", preview.CanonicalURL)
+	assert.Equal(t, &PreviewImage{URL: server.URL + "/a.png"}, preview.Image)
+
+	_, err = NewFetcher(nil).Preview(context.Background(), "ftp://example.com")
+	require.ErrorIs(t, err, ErrInvalidURL)
+}
+
+// TestExtractPreviewHeuristic tests previews of pages without a description or image
+func TestExtractPreviewHeuristic(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><head><title>Story</title></head><body>
+		<p>` + testParagraph + `</p>
+		<img src="/photo.jpg" width="800" height="600">
+	</body></html>`
+
+	preview := ExtractPreview(strings.NewReader(page), "https://example.com/story")
+	assert.Equal(t, testParagraph, preview.Description)
+	assert.Equal(t, &PreviewImage{Height: 600, URL: "https://example.com/photo.jpg", Width: 800}, preview.Image)
+	assert.Equal(t, []string{"description", "image"}, preview.Heuristic)
+
+	// Real metadata is preferred over the body content
+	withJSONLD := strings.Replace(page, "</head>", `<script type="application/ld+json">{"@type":"Article","image":"/lead.jpg"}</script></head>`, 1)
+	preview = ExtractPreview(strings.NewReader(withJSONLD), "https://example.com/story")
+	assert.Equal(t, &PreviewImage{URL: "https://example.com/lead.jpg"}, preview.Image)
+	assert.Equal(t, []string{"description"}, preview.Heuristic)
+}
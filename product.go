@@ -0,0 +1,345 @@
+package metaextractor
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/currency"
+)
+
+// Product availability, following the values of the product:availability meta tag
+const (
+	AvailabilityBackorder    = "backorder"
+	AvailabilityDiscontinued = "discontinued"
+	AvailabilityInStock      = "in stock"
+	AvailabilityOutOfStock   = "out of stock"
+	AvailabilityPreorder     = "preorder"
+)
+
+// Product is the e-commerce metadata of a product page, merged from the
+// product meta tags and the JSON-LD Product and its Offer
+type Product struct {
+	Availability   string `json:"availability,omitempty"` // One of the Availability constants
+	Brand          string `json:"brand,omitempty"`
+	Currency       string `json:"currency,omitempty"`         // ISO 4217 code (e.g. "USD")
+	Price          string `json:"price,omitempty"`            // Decimal with a dot separator (e.g. "1299.99")
+	RetailerItemID string `json:"retailer_item_id,omitempty"` // SKU in JSON-LD
+}
+
+// Field names of the product details set like the tags, matching their JSON paths in the Result
+const (
+	FieldProductBrand          = "product.brand"
+	FieldProductRetailerItemID = "product.retailer_item_id"
+)
+
+// ProductSourceJSONLD is the provenance source of the product details taken
+// from the JSON-LD Product node
+const ProductSourceJSONLD = "json-ld"
+
+// productMeta is the value of a product meta tag and the position of the tag
+type productMeta struct {
+	line   int
+	offset int
+	value  string
+}
+
+// productMetaTags are the meta properties holding product details, the
+// product: ones are preferred over the og: ones
+var productMetaTags = map[string]bool{
+	TagOGAvailability:        true,
+	TagOGBrand:               true,
+	TagOGPriceAmount:         true,
+	TagOGPriceCurrency:       true,
+	TagProductAvailability:   true,
+	TagProductBrand:          true,
+	TagProductPriceAmount:    true,
+	TagProductPriceCurrency:  true,
+	TagProductRetailerItemID: true,
+}
+
+// productTypes are the JSON-LD types describing a product
+var productTypes = []string{"IndividualProduct", "Product", "ProductGroup", "ProductModel"}
+
+// availabilities maps the availability values found in meta tags and
+// schema.org ItemAvailability URLs, compacted by compactAvailability
+var availabilities = map[string]string{
+	"availablefororder":   AvailabilityBackorder,
+	"backorder":           AvailabilityBackorder,
+	"discontinued":        AvailabilityDiscontinued,
+	"instock":             AvailabilityInStock,
+	"instoreonly":         AvailabilityInStock,
+	"limitedavailability": AvailabilityInStock,
+	"onlineonly":          AvailabilityInStock,
+	"oos":                 AvailabilityOutOfStock,
+	"outofstock":          AvailabilityOutOfStock,
+	"preorder":            AvailabilityPreorder,
+	"presale":             AvailabilityPreorder,
+	"soldout":             AvailabilityOutOfStock,
+}
+
+// addMetaProduct records the value of a product meta tag, keeping the first
+// one when a tag is repeated (e.g. one price per currency)
+func (e *extractor) addMetaProduct(t html.Token) {
+	key, content := "", ""
+	for _, attr := range t.Attr {
+		switch attr.Key {
+		case TagName, TagProperty:
+			if k := strings.ToLower(attr.Val); productMetaTags[k] {
+				key = k
+			}
+		case TagContent:
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	if len(key) == 0 || len(content) == 0 {
+		return
+	}
+	if e.options.Warnings {
+		e.checkProduct(key, content)
+	}
+	if e.product == nil {
+		e.product = make(map[string]productMeta)
+	}
+	if _, found := e.product[key]; !found {
+		e.product[key] = productMeta{line: e.line, offset: e.offset, value: content}
+	}
+}
+
+// pickProduct builds the product from the meta tags, filling what they are
+// missing from the JSON-LD. The price and currency are always taken together.
+func (e *extractor) pickProduct() {
+	product := &Product{}
+	meta := func(keys ...string) (string, string) {
+		for _, key := range keys {
+			if m, ok := e.product[key]; ok {
+				return key, m.value
+			}
+		}
+		return "", ""
+	}
+
+	key, price := meta(TagProductPriceAmount, TagOGPriceAmount)
+	if product.Price = NormalizePrice(price); len(product.Price) > 0 {
+		currencyKey := TagProductPriceCurrency
+		if key == TagOGPriceAmount {
+			currencyKey = TagOGPriceCurrency
+		}
+		product.Currency = NormalizeCurrency(e.product[currencyKey].value)
+	}
+	_, availability := meta(TagProductAvailability, TagOGAvailability)
+	product.Availability = NormalizeAvailability(availability)
+	e.setProductMeta(&product.Brand, FieldProductBrand, TagProductBrand, TagOGBrand)
+	e.setProductMeta(&product.RetailerItemID, FieldProductRetailerItemID, TagProductRetailerItemID)
+
+	for _, node := range jsonLDNodes(e.jsonLD) {
+		if jsonLDHasType(node, productTypes...) {
+			e.mergeJSONLDProduct(product, node)
+			break
+		}
+	}
+
+	if *product != (Product{}) {
+		e.result.Product = product
+	}
+}
+
+// setProductMeta sets a product detail from the first of the meta tags found, like the tags
+func (e *extractor) setProductMeta(dst *string, field string, keys ...string) {
+	for _, key := range keys {
+		if m, ok := e.product[key]; ok {
+			e.line, e.offset = m.line, m.offset
+			e.set(dst, field, key, m.value)
+			return
+		}
+	}
+}
+
+// checkProduct reports a price, currency or availability meta tag whose value could not be parsed
+func (e *extractor) checkProduct(key, value string) {
+	what, valid := "", true
+	switch key {
+	case TagOGPriceAmount, TagProductPriceAmount:
+		what, valid = "price", len(NormalizePrice(value)) > 0
+	case TagOGPriceCurrency, TagProductPriceCurrency:
+		what, valid = "currency", len(NormalizeCurrency(value)) > 0
+	case TagOGAvailability, TagProductAvailability:
+		what, valid = "availability", len(NormalizeAvailability(value)) > 0
+	}
+	if !valid {
+		e.warn(WarningInvalidProduct, "", key, what+" "+strconv.Quote(value)+" is not valid")
+	}
+}
+
+// mergeJSONLDProduct fills the fields of product that are still empty from a
+// JSON-LD Product node and the first of its offers that has a price
+func (e *extractor) mergeJSONLDProduct(product *Product, node map[string]any) {
+	// The position of the JSON-LD values is not known
+	e.line, e.offset = 0, 0
+	if brand := strings.TrimSpace(jsonLDString(node["brand"])); len(brand) > 0 {
+		e.setFirst(&product.Brand, FieldProductBrand, ProductSourceJSONLD, brand)
+	}
+	if sku := strings.TrimSpace(jsonLDString(node["sku"])); len(sku) > 0 {
+		e.setFirst(&product.RetailerItemID, FieldProductRetailerItemID, ProductSourceJSONLD, sku)
+	}
+
+	offer := jsonLDOffer(node["offers"])
+	if offer == nil {
+		return
+	}
+	if len(product.Price) == 0 {
+		price, priceCurrency := offer["price"], offer["priceCurrency"]
+		if price == nil {
+			price = offer["lowPrice"]
+		}
+		if spec, ok := offer["priceSpecification"].(map[string]any); ok && price == nil {
+			price, priceCurrency = spec["price"], spec["priceCurrency"]
+		}
+		if product.Price = NormalizePrice(jsonLDPrice(price)); len(product.Price) > 0 {
+			product.Currency = NormalizeCurrency(jsonLDString(priceCurrency))
+		}
+	}
+	if len(product.Availability) == 0 {
+		product.Availability = NormalizeAvailability(jsonLDString(offer["availability"]))
+	}
+}
+
+// jsonLDOffer returns the first offer with a price of an offers property,
+// which may be an Offer, an AggregateOffer or a list of them
+func jsonLDOffer(v any) map[string]any {
+	switch v := v.(type) {
+	case map[string]any:
+		if nested, ok := v["offers"]; ok && !offerHasPrice(v) {
+			if offer := jsonLDOffer(nested); offer != nil {
+				return offer
+			}
+		}
+		return v
+	case []any:
+		var first map[string]any
+		for _, item := range v {
+			if offer := jsonLDOffer(item); offer != nil {
+				if offerHasPrice(offer) {
+					return offer
+				}
+				if first == nil {
+					first = offer
+				}
+			}
+		}
+		return first
+	}
+	return nil
+}
+
+// offerHasPrice returns true if a JSON-LD offer has a price, a low price or a price specification
+func offerHasPrice(offer map[string]any) bool {
+	return offer["price"] != nil || offer["lowPrice"] != nil || offer["priceSpecification"] != nil
+}
+
+// jsonLDPrice returns a JSON-LD price, which may be a number or a string
+func jsonLDPrice(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return jsonLDString(v)
+}
+
+// NormalizePrice will convert a price such as "$1,299.99", "1.299,99 €" or
+// "19" to a decimal string with a dot separator, returning an empty string if
+// the value is not a price. Signed values ("-3") and thousands groups that
+// are not three digits long ("12.5.3") are not prices.
+func NormalizePrice(value string) string {
+	start := strings.IndexFunc(value, isASCIIDigit)
+	if start < 0 || strings.ContainsAny(value[:start], "+-\u2212") {
+		return ""
+	}
+	value = strings.TrimRightFunc(value[start:], func(r rune) bool { return !isASCIIDigit(r) })
+
+	// The last separator is the decimal one, unless it is repeated ("1.299.000")
+	// or it is a lone comma followed by three digits ("1,299")
+	integer, fraction := value, ""
+	if decimal := strings.LastIndexAny(value, ".,"); decimal >= 0 {
+		separator := value[decimal : decimal+1]
+		if strings.Count(value, separator) == 1 &&
+			(separator == "." || len(value)-decimal-1 != 3 || strings.Contains(value, ".")) {
+			integer, fraction = value[:decimal], value[decimal+1:]
+			if strings.ContainsFunc(fraction, func(r rune) bool { return !isASCIIDigit(r) }) {
+				return ""
+			}
+		}
+	}
+	digits, ok := thousandsDigits(integer)
+	if !ok {
+		return ""
+	}
+
+	price := strings.TrimLeft(digits, "0")
+	if len(price) == 0 {
+		price = "0"
+	}
+	if len(fraction) > 0 {
+		price += "." + fraction
+	}
+	return price
+}
+
+// thousandsDigits returns the digits of the integer part of a price, which may
+// be split into groups of three digits by a single kind of thousands separator
+func thousandsDigits(value string) (string, bool) {
+	var b strings.Builder
+	var separator rune
+	group := 0
+	for _, r := range value {
+		switch {
+		case isASCIIDigit(r):
+			b.WriteRune(r)
+			group++
+		case r == '.' || r == ',' || r == '\'' || unicode.IsSpace(r):
+			if separator == 0 && (group == 0 || group > 3) || separator != 0 && (r != separator || group != 3) {
+				return "", false
+			}
+			separator, group = r, 0
+		default:
+			return "", false
+		}
+	}
+	return b.String(), separator == 0 || group == 3
+}
+
+// isASCIIDigit returns true for the digits 0 to 9
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// NormalizeCurrency will return the ISO 4217 code of a currency (e.g. "USD"
+// for "usd"), or an empty string if it is not a recognized code
+func NormalizeCurrency(value string) string {
+	unit, err := currency.ParseISO(strings.TrimSpace(value))
+	if err != nil || unit == (currency.Unit{}) {
+		return ""
+	}
+	return unit.String()
+}
+
+// NormalizeAvailability will return the Availability constant matching an
+// availability such as "in stock", "OutOfStock" or
+// "https://schema.org/PreOrder", or an empty string if it is not known
+func NormalizeAvailability(value string) string {
+	if i := strings.LastIndexByte(value, '/'); i >= 0 {
+		value = value[i+1:]
+	}
+	return availabilities[compactAvailability(value)]
+}
+
+// compactAvailability lowercases an availability and removes its spaces,
+// underscores and hyphens
+func compactAvailability(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
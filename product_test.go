@@ -0,0 +1,244 @@
+package metaextractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizePrice tests converting prices to decimal strings
+func TestNormalizePrice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"19.99", "19.99"},
+		{"19", "19"},
+		{"$1,299.99", "1299.99"},
+		{"1.299,99 €", "1299.99"},
+		{"19,99", "19.99"},
+		{"1,299", "1299"},
+		{"1.299.000", "1299000"},
+		{"1,299,000.50", "1299000.50"},
+		{"1 299,00", "1299.00"},
+		{"CHF 1'299.90", "1299.90"},
+		{"0.5", "0.5"},
+		{"007", "7"},
+		{"0", "0"},
+		{"1 299,00 €", "1299.00"},
+		{"12.5.3", ""},
+		{"12,5,3", ""},
+		{"1,2345.00", ""},
+		{"1234,567.00", ""},
+		{"1.299,000.50", ""},
+		{"1,,299", ""},
+		{"1,299.9x9", ""},
+		{"-3", ""},
+		{"+3", ""},
+		{"$ -3.50", ""},
+		{"−3", ""},
+		{"From 10 to 20", ""},
+
+		{"free", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, NormalizePrice(test.value))
+		})
+	}
+}
+
+// TestNormalizeCurrency tests validating ISO 4217 currency codes
+func TestNormalizeCurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"USD", "USD"},
+		{" eur ", "EUR"},
+		{"Jpy", "JPY"},
+		{"XXX", ""},
+		{"ABC", ""},
+		{"$", ""},
+		{"US Dollar", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, NormalizeCurrency(test.value))
+		})
+	}
+}
+
+// TestNormalizeAvailability tests mapping availabilities to the Availability constants
+func TestNormalizeAvailability(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"in stock", AvailabilityInStock},
+		{"instock", AvailabilityInStock},
+		{"In_Stock", AvailabilityInStock},
+		{"https://schema.org/InStock", AvailabilityInStock},
+		{"http://schema.org/LimitedAvailability", AvailabilityInStock},
+		{"out of stock", AvailabilityOutOfStock},
+		{"oos", AvailabilityOutOfStock},
+		{"https://schema.org/SoldOut", AvailabilityOutOfStock},
+		{"preorder", AvailabilityPreorder},
+		{"schema:PreOrder", ""},
+		{"https://schema.org/PreOrder", AvailabilityPreorder},
+		{"available for order", AvailabilityBackorder},
+		{"https://schema.org/BackOrder", AvailabilityBackorder},
+		{"discontinued", AvailabilityDiscontinued},
+		{"maybe", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, NormalizeAvailability(test.value))
+		})
+	}
+}
+
+// TestExtractWithOptionsProduct tests merging the product from the meta tags and JSON-LD
+func TestExtractWithOptionsProduct(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mockHTML string
+		expected *Product
+	}{
+		{
+			name: "product meta tags",
+			mockHTML: `<head><meta property="og:type" content="product">
+				<meta property="product:price:amount" content="1,299.00">
+				<meta property="product:price:currency" content="usd">
+				<meta property="product:price:amount" content="1199.00">
+				<meta property="product:availability" content="in stock">
+				<meta property="product:brand" content="Acme">
+				<meta property="product:retailer_item_id" content="SKU-42"></head>`,
+			expected: &Product{
+				Availability:   AvailabilityInStock,
+				Brand:          "Acme",
+				Currency:       "USD",
+				Price:          "1299.00",
+				RetailerItemID: "SKU-42",
+			},
+		},
+		{
+			name: "og price tags",
+			mockHTML: `<head><meta property="og:price:amount" content="15,50">
+				<meta property="og:price:currency" content="EUR">
+				<meta property="og:availability" content="oos"></head>`,
+			expected: &Product{Availability: AvailabilityOutOfStock, Currency: "EUR", Price: "15.50"},
+		},
+		{
+			name: "product tags win over og tags",
+			mockHTML: `<head><meta property="og:price:amount" content="10">
+				<meta property="og:price:currency" content="GBP">
+				<meta property="product:price:amount" content="12">
+				<meta property="product:price:currency" content="USD"></head>`,
+			expected: &Product{Currency: "USD", Price: "12"},
+		},
+		{
+			name: "json-ld offer",
+			mockHTML: `<head><script type="application/ld+json">{"@context":"https://schema.org","@type":"Product",
+				"name":"Anvil","sku":"ANV-1","brand":{"@type":"Brand","name":"Acme"},
+				"offers":{"@type":"Offer","price":49.9,"priceCurrency":"USD","availability":"https://schema.org/PreOrder"}}</script></head>`,
+			expected: &Product{
+				Availability:   AvailabilityPreorder,
+				Brand:          "Acme",
+				Currency:       "USD",
+				Price:          "49.9",
+				RetailerItemID: "ANV-1",
+			},
+		},
+		{
+			name: "json-ld aggregate offer and offer lists",
+			mockHTML: `<head><script type="application/ld+json">[{"@type":"WebPage","name":"Shop"},{"@type":"Product","brand":"Acme",
+				"offers":{"@type":"AggregateOffer","lowPrice":"19.99","priceCurrency":"CAD","offers":[{"@type":"Offer","price":"19.99"}]}}]</script></head>`,
+			expected: &Product{Brand: "Acme", Currency: "CAD", Price: "19.99"},
+		},
+		{
+			name: "json-ld price specification",
+			mockHTML: `<head><script type="application/ld+json">{"@type":"Product","offers":[{"@type":"Offer","availability":"InStock"},
+				{"@type":"Offer","priceSpecification":{"@type":"UnitPriceSpecification","price":"5","priceCurrency":"JPY"}}]}</script></head>`,
+			expected: &Product{Currency: "JPY", Price: "5"},
+		},
+		{
+			name: "meta tags are completed by json-ld",
+			mockHTML: `<head><meta property="product:price:amount" content="20">
+				<meta property="product:price:currency" content="USD">
+				<script type="application/ld+json">{"@type":"Product","sku":"X1","brand":"Acme",
+				"offers":{"@type":"Offer","price":"25","priceCurrency":"EUR","availability":"https://schema.org/InStock"}}</script></head>`,
+			expected: &Product{
+				Availability:   AvailabilityInStock,
+				Brand:          "Acme",
+				Currency:       "USD",
+				Price:          "20",
+				RetailerItemID: "X1",
+			},
+		},
+		{
+			name:     "not a product",
+			mockHTML: `<head><script type="application/ld+json">{"@type":"NewsArticle","offers":{"price":"1"}}</script></head>`,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), ExtractOptions{Product: true})
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.Product)
+			assert.Nil(t, result.JSONLD)
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><meta property="product:brand" content="Acme"></head>`), ExtractOptions{})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Product)
+	})
+
+	t.Run("brand and sku are set like the tags", func(t *testing.T) {
+		page := `<head><meta property="og:brand" content="Other">
+			<meta property="product:brand" content="  Acme <b>Tools</b>  ">
+			<script type="application/ld+json">{"@type":"Product","sku":"` + strings.Repeat("9", 20000) + `"}</script></head>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{
+			Normalize: true, Product: true, Provenance: true, StripTags: true, Warnings: true,
+		})
+		require.NotNil(t, result)
+		require.NotNil(t, result.Product)
+		assert.Equal(t, "Acme Tools", result.Product.Brand)
+		assert.Len(t, result.Product.RetailerItemID, MaxFieldLength)
+		assert.Equal(t, Provenance{Line: 2, Offset: strings.Index(page, `<meta property="product:brand"`), Source: TagProductBrand}, result.Provenance[FieldProductBrand])
+		assert.Equal(t, Provenance{Source: ProductSourceJSONLD, Truncated: true}, result.Provenance[FieldProductRetailerItemID])
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, WarningTruncated, result.Warnings[0].Code)
+		assert.Equal(t, FieldProductRetailerItemID, result.Warnings[0].Field)
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		page := `<head><meta property="product:price:amount" content="call us">
+			<meta property="product:price:currency" content="dollars">
+			<meta property="product:availability" content="ask"></head>`
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Product: true, Warnings: true})
+		require.NotNil(t, result)
+		assert.Nil(t, result.Product)
+		require.Len(t, result.Warnings, 3)
+		assert.Equal(t, WarningInvalidProduct, result.Warnings[0].Code)
+		assert.Equal(t, TagProductPriceAmount, result.Warnings[0].Tag)
+		assert.Equal(t, TagProductPriceCurrency, result.Warnings[1].Tag)
+		assert.Equal(t, TagProductAvailability, result.Warnings[2].Tag)
+	})
+}
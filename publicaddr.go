@@ -0,0 +1,77 @@
+package metaextractor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a client made with NewPublicClient
+// would connect to a loopback, private, link-local or other non-public address
+var ErrNonPublicAddress = errors.New("refusing to connect to a non-public address")
+
+// nonPublicPrefixes are the special-purpose ranges that net/netip does not
+// classify, such as carrier-grade NAT, benchmarking and documentation ranges
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64, which can reach any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// NewPublicClient will return an HTTP client that only connects to public IP addresses
+//
+// The address is checked for every connection after the host name is
+// resolved, so redirects to a non-public address and host names resolving to
+// one fail with ErrNonPublicAddress as well. Proxies from the environment are
+// not used, since they would connect to the address on the client's behalf
+func NewPublicClient(timeout time.Duration) *http.Client {
+	return newGuardedClient(timeout, func(addr netip.AddrPort) bool {
+		return isPublicAddress(addr.Addr())
+	})
+}
+
+// newGuardedClient returns an HTTP client that refuses to connect to the addresses not allowed
+func newGuardedClient(timeout time.Duration, allow func(addr netip.AddrPort) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !allow(addr) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// isPublicAddress returns true if addr is a public unicast address
+func isPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package metaextractor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsPublicAddress tests telling public addresses from the others
+func TestIsPublicAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+	for _, test := range tests {
+		t.Run(test.addr, func(t *testing.T) {
+			assert.Equal(t, test.expected, isPublicAddress(netip.MustParseAddr(test.addr)))
+		})
+	}
+}
+
+// TestNewPublicClient tests that the public client refuses non-public addresses
+func TestNewPublicClient(t *testing.T) {
+	t.Parallel()
+
+	server := newTestPageServer(t, `<html><head><title>`+testTitle+`</title></head></html>`)
+
+	t.Run("loopback", func(t *testing.T) {
+		resp, err := NewPublicClient(time.Second).Get(server.URL)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		require.ErrorIs(t, err, ErrNonPublicAddress)
+	})
+
+	t.Run("cloud metadata address", func(t *testing.T) {
+		resp, err := NewPublicClient(time.Second).Get("http://169.254.169.254/latest/meta-data/")
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		require.ErrorIs(t, err, ErrNonPublicAddress)
+	})
+
+	t.Run("redirects are checked", func(t *testing.T) {
+		redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+		t.Cleanup(redirect.Close)
+
+		// Only the redirecting server is allowed, standing in for a public page
+		allowed := netip.MustParseAddrPort(redirect.Listener.Addr().String())
+		client := newGuardedClient(time.Second, func(addr netip.AddrPort) bool { return addr == allowed })
+
+		resp, err := client.Get(redirect.URL)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		require.ErrorIs(t, err, ErrNonPublicAddress)
+	})
+
+	t.Run("not retried by the fetcher", func(t *testing.T) {
+		clock := newFakeClock()
+		f := NewFetcher(&FetcherOptions{Client: NewPublicClient(time.Second), Clock: clock})
+
+		_, err := f.Fetch(context.Background(), server.URL)
+		require.ErrorIs(t, err, ErrNonPublicAddress)
+		assert.Empty(t, clock.sleeps)
+	})
+}
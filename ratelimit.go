@@ -0,0 +1,193 @@
+package metaextractor
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used by the fetch layer and the date limits
+//
+// It exists so rate limiting, crawl delays, retry backoff and the dates in the
+// future can be tested deterministically with a fake clock
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// systemClock is the Clock backed by the real time
+type systemClock struct{}
+
+// Now will return the current time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep will pause for d or until ctx is canceled
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hostLimiter spaces out requests per host using a token bucket, a fixed
+// crawl delay between requests, and pauses requested by the server (Retry-After)
+type hostLimiter struct {
+	burst      float64
+	clock      Clock
+	crawlDelay time.Duration
+	hosts      map[string]*hostBucket
+	mu         sync.Mutex
+	rate       float64 // Tokens per second, zero disables the token bucket
+}
+
+// hostBucket is the limiter state kept for a single host
+type hostBucket struct {
+	crawlDelay time.Duration // Per-host override of the crawl delay (e.g. from robots.txt)
+	last       time.Time     // Last time the tokens were refilled
+	next       time.Time     // Earliest time the next request may start
+	tokens     float64
+}
+
+// newHostLimiter will create a new hostLimiter
+func newHostLimiter(clock Clock, rate float64, burst int, crawlDelay time.Duration) *hostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &hostLimiter{
+		burst:      float64(burst),
+		clock:      clock,
+		crawlDelay: crawlDelay,
+		hosts:      make(map[string]*hostBucket),
+		rate:       rate,
+	}
+}
+
+// bucket returns the state for host, l.mu must be held
+func (l *hostLimiter) bucket(host string) *hostBucket {
+	b, ok := l.hosts[host]
+	if !ok {
+		b = &hostBucket{crawlDelay: l.crawlDelay, tokens: l.burst}
+		l.hosts[host] = b
+	}
+	return b
+}
+
+// reserve books the next available start time for a request to host
+func (l *hostLimiter) reserve(host string) (start, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(host)
+	now = l.clock.Now()
+	start = now
+	if b.next.After(start) {
+		start = b.next
+	}
+
+	if l.rate > 0 {
+		// A refill time in the future means earlier callers already emptied the bucket
+		if b.last.After(start) {
+			start = b.last
+		}
+		if !b.last.IsZero() {
+			b.tokens = math.Min(l.burst, b.tokens+start.Sub(b.last).Seconds()*l.rate)
+		}
+		b.last = start
+		if b.tokens < 1 {
+			start = start.Add(time.Duration((1 - b.tokens) / l.rate * float64(time.Second)))
+			b.tokens = 1
+			b.last = start
+		}
+		b.tokens--
+	}
+
+	if next := start.Add(b.crawlDelay); next.After(b.next) {
+		b.next = next
+	}
+	return start, now
+}
+
+// wait blocks until a request to host is allowed to start
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	start, now := l.reserve(host)
+	return l.clock.Sleep(ctx, start.Sub(now))
+}
+
+// pause stops any new requests to host from starting before until
+func (l *hostLimiter) pause(host string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b := l.bucket(host); until.After(b.next) {
+		b.next = until
+	}
+}
+
+// setCrawlDelay overrides the crawl delay for a single host (never lowering the configured delay)
+func (l *hostLimiter) setCrawlDelay(host string, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b := l.bucket(host); delay > b.crawlDelay {
+		b.crawlDelay = delay
+	}
+}
+
+// backoff computes the delay before a retry using exponential backoff with
+// "equal jitter": half of the delay is fixed and the other half is random
+type backoff struct {
+	base   time.Duration
+	max    time.Duration
+	random func() float64 // Returns a value in [0.0, 1.0)
+}
+
+// delay returns the delay before retry number attempt (starting at zero)
+func (b backoff) delay(attempt int) time.Duration {
+	d := b.max
+	if attempt < 32 {
+		if exp := b.base << uint(attempt); exp > 0 && exp < b.max {
+			d = exp
+		}
+	}
+	half := d / 2
+	return half + time.Duration(b.random()*float64(d-half))
+}
+
+// defaultRandom is the default source of jitter
+func defaultRandom() float64 {
+	return rand.Float64() //nolint:gosec // jitter does not need a secure random source
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := when.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
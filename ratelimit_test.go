@@ -0,0 +1,292 @@
+package metaextractor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a deterministic Clock, sleeping simply moves the time forward
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// newFakeClock will create a new fake clock
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 12, 10, 0, 0, 0, time.UTC)}
+}
+
+// Now will return the fake time
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep records the sleep and moves the fake time forward
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// Sleeps will return the recorded sleeps
+func (c *fakeClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+// reserveOffsets reserves n requests to host and returns their start offsets from the clock's time
+func reserveOffsets(l *hostLimiter, clock *fakeClock, host string, n int) []time.Duration {
+	base := clock.Now()
+	offsets := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		start, _ := l.reserve(host)
+		offsets = append(offsets, start.Sub(base))
+	}
+	return offsets
+}
+
+// TestHostLimiterReserve will test the token bucket and crawl delay scheduling
+func TestHostLimiterReserve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		rate       float64
+		burst      int
+		crawlDelay time.Duration
+		expected   []time.Duration
+	}{
+		{"no limits", 0, 0, 0, []time.Duration{0, 0, 0}},
+		{"two per second", 2, 1, 0, []time.Duration{0, 500 * time.Millisecond, time.Second}},
+		{"burst of three", 2, 3, 0, []time.Duration{0, 0, 0, 500 * time.Millisecond, time.Second}},
+		{"crawl delay", 0, 0, 2 * time.Second, []time.Duration{0, 2 * time.Second, 4 * time.Second}},
+		{"crawl delay slower than rate", 10, 1, time.Second, []time.Duration{0, time.Second, 2 * time.Second}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := newHostLimiter(clock, test.rate, test.burst, test.crawlDelay)
+			assert.Equal(t, test.expected, reserveOffsets(l, clock, "example.com", len(test.expected)))
+
+			// Hosts are limited independently
+			assert.Equal(t, time.Duration(0), reserveOffsets(l, clock, "other.example.com", 1)[0])
+		})
+	}
+}
+
+// TestHostLimiterRefill will test that tokens refill as time passes
+func TestHostLimiterRefill(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	l := newHostLimiter(clock, 1, 2, 0)
+	assert.Equal(t, []time.Duration{0, 0, time.Second}, reserveOffsets(l, clock, "example.com", 3))
+
+	require.NoError(t, clock.Sleep(context.Background(), 10*time.Second))
+	assert.Equal(t, []time.Duration{0, 0, time.Second}, reserveOffsets(l, clock, "example.com", 3))
+}
+
+// TestHostLimiterPause will test pausing and overriding the crawl delay of a host
+func TestHostLimiterPause(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	l := newHostLimiter(clock, 0, 0, 0)
+
+	l.pause("example.com", clock.Now().Add(10*time.Second))
+	l.setCrawlDelay("example.com", 3*time.Second)
+	assert.Equal(t, []time.Duration{10 * time.Second, 13 * time.Second}, reserveOffsets(l, clock, "example.com", 2))
+
+	// A pause in the past is ignored
+	l.pause("other.example.com", clock.Now().Add(-time.Second))
+	require.NoError(t, l.wait(context.Background(), "other.example.com"))
+	assert.Empty(t, clock.Sleeps())
+}
+
+// TestBackoffDelay will test the exponential backoff with jitter
+func TestBackoffDelay(t *testing.T) {
+	t.Parallel()
+
+	low := backoff{base: time.Second, max: 10 * time.Second, random: func() float64 { return 0 }}
+	high := backoff{base: time.Second, max: 10 * time.Second, random: func() float64 { return 0.999999 }}
+
+	tests := []struct {
+		attempt int
+		min     time.Duration
+	}{
+		{0, 500 * time.Millisecond},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{64, 5 * time.Second},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.min, low.delay(test.attempt), "attempt %d", test.attempt)
+		assert.InDelta(t, float64(2*test.min), float64(high.delay(test.attempt)), float64(time.Millisecond), "attempt %d", test.attempt)
+	}
+}
+
+// TestParseRetryAfter will test parsing the Retry-After header
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 12, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"seconds", "120", 2 * time.Minute, true},
+		{"seconds with spaces", " 5 ", 5 * time.Second, true},
+		{"http date", "Sun, 12 May 2024 10:00:30 GMT", 30 * time.Second, true},
+		{"http date in the past", "Sun, 12 May 2024 09:00:00 GMT", 0, true},
+		{"empty", "", 0, false},
+		{"negative", "-5", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, ok := parseRetryAfter(test.value, now)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+// newFlakyServer returns a server that responds with the given statuses (and headers) in order, then 200
+func newFlakyServer(t *testing.T, requests *int32, statuses []int, retryAfter string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(atomic.AddInt32(requests, 1)) - 1
+		if n < len(statuses) {
+			if len(retryAfter) > 0 {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statuses[n])
+			return
+		}
+		_, _ = w.Write([]byte(`<title>` + testTitle + `</title>`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFetcherRetries will test retrying failed requests
+func TestFetcherRetries(t *testing.T) {
+	t.Parallel()
+
+	zero := func() float64 { return 0 }
+
+	t.Run("retry after is honored", func(t *testing.T) {
+		var requests int32
+		server := newFlakyServer(t, &requests, []int{http.StatusServiceUnavailable}, "3")
+		clock := newFakeClock()
+
+		tags, err := NewFetcher(&FetcherOptions{Clock: clock, Random: zero}).Extract(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, testTitle, tags.Title)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+		// One sleep for the Retry-After and none for the limiter, which was paused for the same period
+		assert.Equal(t, []time.Duration{3 * time.Second}, clock.Sleeps())
+	})
+
+	t.Run("exponential backoff", func(t *testing.T) {
+		var requests int32
+		server := newFlakyServer(t, &requests, []int{http.StatusInternalServerError, http.StatusBadGateway}, "")
+		clock := newFakeClock()
+
+		_, err := NewFetcher(&FetcherOptions{Clock: clock, Random: zero, RetryBaseDelay: time.Second}).Extract(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, clock.Sleeps())
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		var requests int32
+		server := newFlakyServer(t, &requests, []int{429, 429, 429, 429}, "")
+		clock := newFakeClock()
+
+		_, err := NewFetcher(&FetcherOptions{Clock: clock, Random: zero, MaxRetries: 1}).Extract(context.Background(), server.URL)
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("retries can be disabled", func(t *testing.T) {
+		var requests int32
+		server := newFlakyServer(t, &requests, []int{http.StatusServiceUnavailable}, "")
+
+		_, err := NewFetcher(&FetcherOptions{Clock: newFakeClock(), MaxRetries: -1}).Extract(context.Background(), server.URL)
+		require.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var requests int32
+		server := newFlakyServer(t, &requests, []int{http.StatusNotFound}, "")
+
+		_, err := NewFetcher(&FetcherOptions{Clock: newFakeClock()}).Extract(context.Background(), server.URL)
+		require.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("retry after longer than the max delay gives up", func(t *testing.T) {
+		var requests int32
+		server := newFlakyServer(t, &requests, []int{http.StatusTooManyRequests}, "3600")
+		clock := newFakeClock()
+		f := NewFetcher(&FetcherOptions{Clock: clock, RetryMaxDelay: time.Minute})
+
+		_, err := f.Extract(context.Background(), server.URL)
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, time.Hour, statusErr.RetryAfter)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+		// The host stays paused for the next request
+		_, err = f.Extract(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{time.Hour}, clock.Sleeps())
+	})
+}
+
+// TestFetcherRateLimit will test the per-host rate limit of the fetcher
+func TestFetcherRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server := newTestPageServer(t, `<title>`+testTitle+`</title>`)
+	clock := newFakeClock()
+	f := NewFetcher(&FetcherOptions{Clock: clock, HostRate: 4, CrawlDelay: time.Second})
+
+	for i := 0; i < 3; i++ {
+		_, err := f.Fetch(context.Background(), server.URL)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, clock.Sleeps())
+}
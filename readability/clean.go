@@ -0,0 +1,398 @@
+package readability
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// keptAttributes are the only attributes left on the elements of an article
+var keptAttributes = map[string]bool{
+	"alt":    true,
+	"height": true,
+	"href":   true,
+	"src":    true,
+	"srcset": true,
+	"title":  true,
+	"width":  true,
+}
+
+// conditionalTags are removed from an article when they look like boilerplate
+var conditionalTags = map[atom.Atom]bool{
+	atom.Div:      true,
+	atom.Fieldset: true,
+	atom.Form:     true,
+	atom.Ol:       true,
+	atom.Section:  true,
+	atom.Table:    true,
+	atom.Ul:       true,
+}
+
+// textBlockTags end a paragraph in the plain text of an article
+var textBlockTags = map[atom.Atom]bool{
+	atom.Blockquote: true,
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Tr:         true,
+	atom.Ul:         true,
+}
+
+// maxShareTextLength is the longest text of an element removed for being a share widget
+const maxShareTextLength = 500
+
+// lazySources are the attributes holding the real address of a lazy loaded image
+var lazySources = []string{"data-src", "data-original", "data-lazy-src", "data-url"}
+
+// clean removes the leftovers of the page from the article and tidies up what remains
+func (s *scorer) clean(article *html.Node) {
+	var removed []*html.Node
+	walk(article, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n == article {
+			return true
+		}
+		if isShareWidget(n) {
+			removed = append(removed, n)
+			return false
+		}
+		switch n.DataAtom {
+		case atom.H1, atom.H2, atom.H3:
+			if s.classWeight(n) < 0 {
+				removed = append(removed, n)
+				return false
+			}
+		case atom.Img:
+			fixLazyImage(n)
+		}
+		return true
+	})
+	removeAll(removed)
+
+	if s.flags&flagCleanConditionally != 0 {
+		s.cleanConditionally(article)
+	}
+
+	removed = removed[:0]
+	walk(article, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.DataAtom == atom.P && isEmptyParagraph(n) {
+			removed = append(removed, n)
+			return false
+		}
+		return true
+	})
+	removeAll(removed)
+
+	walk(article, func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			s.cleanAttributes(n)
+		}
+		return true
+	})
+}
+
+// cleanConditionally removes the lists, tables and containers of the article
+// that have too many links, images or inputs for the text they hold, starting
+// with the innermost ones
+func (s *scorer) cleanConditionally(article *html.Node) {
+	var elements []*html.Node
+	walk(article, func(n *html.Node) bool {
+		if n != article && n.Type == html.ElementNode && conditionalTags[n.DataAtom] {
+			elements = append(elements, n)
+		}
+		return true
+	})
+
+	for i := len(elements) - 1; i >= 0; i-- {
+		if n := elements[i]; n.Parent != nil && s.isBoilerplate(n) {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// isBoilerplate reports whether an element of the article looks like it is not part of it
+func (s *scorer) isBoilerplate(n *html.Node) bool {
+	if n.DataAtom == atom.Table && findElement(n, atom.Th) != nil {
+		return false // Data tables are kept
+	}
+
+	weight := s.classWeight(n)
+	if s.scores[n]+weight < 0 {
+		return true
+	}
+
+	text := innerText(n)
+	if strings.Count(text, ",") >= 10 {
+		return false
+	}
+
+	var images, inputs, items, paragraphs, embeds int
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Img:
+				images++
+			case atom.Input:
+				inputs++
+			case atom.Li:
+				items++
+			case atom.P:
+				paragraphs++
+			case atom.Embed, atom.Video, atom.Audio:
+				embeds++
+			}
+		}
+		return true
+	})
+	isList := n.DataAtom == atom.Ul || n.DataAtom == atom.Ol
+	density := linkDensity(n)
+
+	switch {
+	case images > 1 && float64(paragraphs)/float64(images) < 0.5 && findElement(n, atom.Figure) == nil:
+		return true
+	case !isList && items-100 > paragraphs:
+		return true
+	case inputs > paragraphs/3:
+		return true
+	case !isList && len(text) < minScoredTextLength && (images == 0 || images > 2) && embeds == 0:
+		return true
+	case weight < classWeight && density > 0.2:
+		return true
+	case weight >= classWeight && density > 0.5:
+		return true
+	case embeds > 1 && len(text) < 75:
+		return true
+	}
+	return false
+}
+
+// cleanAttributes drops presentational attributes and resolves the addresses left
+func (s *scorer) cleanAttributes(n *html.Node) {
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		if !keptAttributes[attr.Key] || len(attr.Namespace) > 0 {
+			continue
+		}
+		switch attr.Key {
+		case "href", "src":
+			attr.Val = resolveURL(s.base, attr.Val)
+		case "srcset":
+			attr.Val = resolveSrcset(s.base, attr.Val)
+		}
+		attrs = append(attrs, attr)
+	}
+	n.Attr = attrs
+}
+
+// isShareWidget reports whether n holds the share buttons of the page
+func isShareWidget(n *html.Node) bool {
+	name := strings.ToLower(getAttr(n, "class") + " " + getAttr(n, "id"))
+	return strings.Contains(name, "share") && len(innerText(n)) < maxShareTextLength
+}
+
+// fixLazyImage copies the real address of a lazy loaded image into its src and srcset
+func fixLazyImage(n *html.Node) {
+	src := getAttr(n, "src")
+	if len(src) == 0 || strings.HasPrefix(src, "data:") {
+		for _, key := range lazySources {
+			if lazy := getAttr(n, key); len(lazy) > 0 {
+				setAttr(n, "src", lazy)
+				break
+			}
+		}
+	}
+	if srcset := getAttr(n, "data-srcset"); len(srcset) > 0 && len(getAttr(n, "srcset")) == 0 {
+		setAttr(n, "srcset", srcset)
+	}
+}
+
+// isEmptyParagraph reports whether a paragraph has no text and no media
+func isEmptyParagraph(n *html.Node) bool {
+	if len(innerText(n)) > 0 {
+		return false
+	}
+	empty := true
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Img, atom.Picture, atom.Video, atom.Audio, atom.Embed:
+				empty = false
+			}
+		}
+		return empty
+	})
+	return empty
+}
+
+// articleText returns the plain text of the article, with one blank line between blocks
+func articleText(article *html.Node) string {
+	var blocks []string
+	var block strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(block.String()), " "); len(text) > 0 {
+			blocks = append(blocks, text)
+		}
+		block.Reset()
+	}
+
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			block.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Img:
+				return
+			case atom.Td, atom.Th:
+				block.WriteByte(' ')
+			}
+		}
+		isBlock := n.Type == html.ElementNode && textBlockTags[n.DataAtom]
+		if isBlock {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+		if isBlock {
+			flush()
+		}
+	}
+	visit(article)
+	flush()
+	return strings.Join(blocks, "\n\n")
+}
+
+// innerText returns the text inside n with its whitespace collapsed
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+			b.WriteByte(' ')
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// walk calls fn for n and its descendants in document order, skipping the
+// descendants of a node when fn returns false
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// findElement returns the first element with the tag a inside n, or nil
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found == nil && c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+// moveChildren moves the children of src to the end of dst
+func moveChildren(dst, src *html.Node) {
+	for c := src.FirstChild; c != nil; c = src.FirstChild {
+		src.RemoveChild(c)
+		dst.AppendChild(c)
+	}
+}
+
+// removeAll removes the nodes from their parents
+func removeAll(nodes []*html.Node) {
+	for _, n := range nodes {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// getAttr returns the value of the attribute key of n, or an empty string
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether n has the attribute key
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setAttr sets the attribute key of n to val
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// resolveURL resolves ref against base, returning ref unchanged when either is unusable
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || len(ref) == 0 {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveSrcset resolves every candidate address of a srcset against base
+func resolveSrcset(base *url.URL, srcset string) string {
+	if base == nil {
+		return srcset
+	}
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = resolveURL(base, fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
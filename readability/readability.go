@@ -0,0 +1,156 @@
+// Package readability will extract the main article of a page, the way the
+// reader view of a browser does
+//
+// Every block of text is scored by its length and number of commas, the score
+// is passed on to its ancestors and the ancestor with the best score (after
+// penalizing links and boilerplate class names) is taken as the article.
+// Its siblings that look like more of the article are added, then the content
+// is cleaned of forms, share widgets and other leftovers.
+//
+// The scoring follows Mozilla's Readability (https://github.com/mozilla/readability).
+package readability
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+)
+
+// Defaults
+const (
+	DefaultCharThreshold   = 500 // Articles shorter than this are extracted again with less cleaning
+	DefaultMaxDocumentSize = 5 << 20
+	DefaultWordsPerMinute  = 200
+)
+
+// ErrNoContent is returned when no article could be found in the page
+var ErrNoContent = errors.New("readability: no article content found")
+
+// Options are the options used by Parse
+type Options struct {
+	CharThreshold   int    // Minimum length of the article text before the cleaning is relaxed
+	MaxDocumentSize int64  // Maximum number of bytes read from the page
+	URL             string // Address of the page, used to resolve relative links and images
+	WordsPerMinute  int    // Reading speed used for the reading time
+}
+
+// DefaultOptions will return the default options for Parse
+func DefaultOptions() *Options {
+	return &Options{
+		CharThreshold:   DefaultCharThreshold,
+		MaxDocumentSize: DefaultMaxDocumentSize,
+		WordsPerMinute:  DefaultWordsPerMinute,
+	}
+}
+
+// Article is the main content of a page
+type Article struct {
+	Content            string `json:"content"`              // Cleaned HTML of the article
+	LeadImage          string `json:"lead_image,omitempty"` // First image of the article, or the og:image of the page
+	ReadingTimeMinutes int    `json:"reading_time_minutes"` // Rounded up, so any article takes at least a minute
+	Text               string `json:"text"`                 // Plain text of the article, with paragraphs separated by blank lines
+	Title              string `json:"title,omitempty"`
+	WordCount          int    `json:"word_count"`
+}
+
+// Parse will extract the main article of the HTML page read from r, any missing
+// options are replaced with their defaults
+//
+// When the article is shorter than the CharThreshold, the page is extracted
+// again with less aggressive cleaning and the longest result is kept
+func Parse(r io.Reader, options *Options) (*Article, error) {
+	opts := withDefaults(options)
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxDocumentSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var base *url.URL
+	if u, parseErr := url.Parse(opts.URL); parseErr == nil && u.IsAbs() {
+		base = u
+	}
+
+	var best *attempt
+	for _, f := range []flags{
+		flagStripUnlikely | flagWeightClasses | flagCleanConditionally,
+		flagWeightClasses | flagCleanConditionally,
+		flagWeightClasses,
+		0,
+	} {
+		var doc *html.Node
+		if doc, err = html.Parse(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		a := grabArticle(doc, f, base)
+		if a == nil {
+			continue
+		}
+		if best == nil || len(a.text) > len(best.text) {
+			best = a
+		}
+		if len(a.text) >= opts.CharThreshold {
+			break
+		}
+	}
+	if best == nil || len(best.text) == 0 {
+		return nil, ErrNoContent
+	}
+
+	var content bytes.Buffer
+	if err = html.Render(&content, best.node); err != nil {
+		return nil, err
+	}
+
+	words := len(strings.Fields(best.text))
+	article := &Article{
+		Content:            content.String(),
+		LeadImage:          best.leadImage,
+		ReadingTimeMinutes: readingMinutes(words, opts.WordsPerMinute),
+		Text:               best.text,
+		WordCount:          words,
+	}
+
+	meta := metaextractor.ExtractWithOptions(bytes.NewReader(data), metaextractor.ExtractOptions{
+		CleanTitle: true,
+		Normalize:  true,
+		URL:        opts.URL,
+	})
+	article.Title = meta.Title
+	if len(meta.OGTitle) > 0 {
+		article.Title = meta.OGTitle
+	}
+	if len(article.LeadImage) == 0 && len(meta.OGImage) > 0 {
+		article.LeadImage = resolveURL(base, meta.OGImage)
+	}
+	return article, nil
+}
+
+// withDefaults returns a copy of options with any missing values replaced by defaults
+func withDefaults(options *Options) *Options {
+	opts := DefaultOptions()
+	if options == nil {
+		return opts
+	}
+	o := *options
+	if o.CharThreshold <= 0 {
+		o.CharThreshold = opts.CharThreshold
+	}
+	if o.MaxDocumentSize <= 0 {
+		o.MaxDocumentSize = opts.MaxDocumentSize
+	}
+	if o.WordsPerMinute <= 0 {
+		o.WordsPerMinute = opts.WordsPerMinute
+	}
+	return &o
+}
+
+// readingMinutes returns the minutes needed to read words at wordsPerMinute, rounded up
+func readingMinutes(words, wordsPerMinute int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
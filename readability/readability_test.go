@@ -0,0 +1,173 @@
+package readability
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture is a single entry of testdata/expected.json
+type fixture struct {
+	Contains    []string `json:"contains"`
+	Error       bool     `json:"error"`
+	File        string   `json:"file"`
+	LeadImage   string   `json:"lead_image"`
+	MaxWords    int      `json:"max_words"`
+	MinWords    int      `json:"min_words"`
+	NotContains []string `json:"not_contains"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+}
+
+// TestParseCorpus tests extracting the article of every saved page in testdata
+func TestParseCorpus(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/expected.json")
+	require.NoError(t, err)
+
+	var fixtures []fixture
+	require.NoError(t, json.Unmarshal(data, &fixtures))
+	require.NotEmpty(t, fixtures)
+
+	for _, test := range fixtures {
+		t.Run(test.File, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open(filepath.Join("testdata", test.File))
+			require.NoError(t, err)
+			defer func() { _ = f.Close() }()
+
+			article, err := Parse(f, &Options{URL: test.URL})
+			if test.Error {
+				require.ErrorIs(t, err, ErrNoContent)
+				assert.Nil(t, article)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, article)
+
+			assert.Equal(t, test.Title, article.Title)
+			if len(test.LeadImage) > 0 {
+				assert.Equal(t, test.LeadImage, article.LeadImage)
+			}
+			for _, phrase := range test.Contains {
+				assert.Contains(t, article.Text, phrase)
+			}
+			for _, phrase := range test.NotContains {
+				assert.NotContains(t, article.Text, phrase)
+				assert.NotContains(t, article.Content, phrase)
+			}
+			assert.GreaterOrEqual(t, article.WordCount, test.MinWords)
+			assert.LessOrEqual(t, article.WordCount, test.MaxWords)
+			assert.Equal(t, len(strings.Fields(article.Text)), article.WordCount)
+			assert.Equal(t, readingMinutes(article.WordCount, DefaultWordsPerMinute), article.ReadingTimeMinutes)
+
+			assert.NotContains(t, article.Content, "<script")
+			assert.NotContains(t, article.Content, "class=")
+			assert.NotContains(t, article.Content, "<nav")
+		})
+	}
+}
+
+// TestParse tests the content, text and images of an extracted article
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	paragraph := "This paragraph is long enough to be scored, with a comma or two, and it keeps going for a while so that it is well past the threshold."
+
+	t.Run("content is cleaned and resolved", func(t *testing.T) {
+		t.Parallel()
+
+		html := `<html><body><div class="post" style="color:red">` +
+			`<p onclick="x()">` + paragraph + ` <a href="/more" class="link">Read more</a></p>` +
+			`<p></p>` +
+			`<p><img data-src="photo.jpg" src="data:image/gif;base64,R0lGOD" srcset="a.jpg 1x, /b.jpg 2x"></p>` +
+			`<p>` + paragraph + `</p>` +
+			`</div></body></html>`
+		article, err := Parse(strings.NewReader(html), &Options{URL: "https://example.com/blog/post"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://example.com/blog/photo.jpg", article.LeadImage)
+		assert.Contains(t, article.Content, `<a href="https://example.com/more">Read more</a>`)
+		assert.Contains(t, article.Content, `srcset="https://example.com/blog/a.jpg 1x, https://example.com/b.jpg 2x"`)
+		assert.NotContains(t, article.Content, "onclick")
+		assert.NotContains(t, article.Content, "style")
+		assert.NotContains(t, article.Content, "<p></p>")
+		assert.Equal(t, paragraph+" Read more\n\n"+paragraph, article.Text)
+	})
+
+	t.Run("hidden elements are removed", func(t *testing.T) {
+		t.Parallel()
+
+		html := `<body><article><p>` + paragraph + `</p>` +
+			`<p hidden>Hidden paragraph</p><p style="display: none">Invisible paragraph</p>` +
+			`<div aria-hidden="true">Decoration</div></article></body>`
+		article, err := Parse(strings.NewReader(html), nil)
+		require.NoError(t, err)
+		assert.Equal(t, paragraph, article.Text)
+	})
+
+	t.Run("lead image falls back to og:image", func(t *testing.T) {
+		t.Parallel()
+
+		html := `<head><meta property="og:image" content="/og.png"></head><body><article><p>` + paragraph + `</p></article></body>`
+		article, err := Parse(strings.NewReader(html), &Options{URL: "https://example.com/a"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/og.png", article.LeadImage)
+	})
+
+	t.Run("short pages are extracted without cleaning", func(t *testing.T) {
+		t.Parallel()
+
+		html := `<body><div class="comment-body"><p>Just a short note, kept anyway.</p></div></body>`
+		article, err := Parse(strings.NewReader(html), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Just a short note, kept anyway.", article.Text)
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		t.Parallel()
+
+		article, err := Parse(strings.NewReader(`<html><head><title>Empty</title></head><body></body></html>`), nil)
+		require.ErrorIs(t, err, ErrNoContent)
+		assert.Nil(t, article)
+	})
+}
+
+// TestWithDefaults tests replacing missing options with their defaults
+func TestWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, DefaultOptions(), withDefaults(nil))
+
+	opts := withDefaults(&Options{WordsPerMinute: 300, URL: "https://example.com"})
+	assert.Equal(t, DefaultCharThreshold, opts.CharThreshold)
+	assert.Equal(t, int64(DefaultMaxDocumentSize), opts.MaxDocumentSize)
+	assert.Equal(t, 300, opts.WordsPerMinute)
+	assert.Equal(t, "https://example.com", opts.URL)
+}
+
+// TestReadingMinutes tests estimating the reading time of an article
+func TestReadingMinutes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, readingMinutes(0, 200))
+	assert.Equal(t, 1, readingMinutes(200, 200))
+	assert.Equal(t, 2, readingMinutes(300, 200))
+	assert.Equal(t, 1, readingMinutes(83, 250))
+}
+
+// TestArticleJSON tests that the reading time is encoded in minutes
+func TestArticleJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(&Article{ReadingTimeMinutes: 5, WordCount: 1000})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"reading_time_minutes":5`)
+}
@@ -0,0 +1,433 @@
+package readability
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// flags turn the steps of an extraction attempt on or off, later attempts
+// drop them one by one when the article found is too short
+type flags uint8
+
+const (
+	flagStripUnlikely flags = 1 << iota
+	flagWeightClasses
+	flagCleanConditionally
+)
+
+// Scoring constants
+const (
+	classWeight          = 25
+	maxAncestorLevels    = 5
+	minScoredTextLength  = 25
+	minSiblingScore      = 10
+	siblingScoreFraction = 0.2
+)
+
+// removedTags are never part of an article
+var removedTags = map[atom.Atom]bool{
+	atom.Aside:    true,
+	atom.Button:   true,
+	atom.Dialog:   true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Header:   true,
+	atom.Iframe:   true,
+	atom.Input:    true,
+	atom.Link:     true,
+	atom.Math:     true,
+	atom.Meta:     true,
+	atom.Nav:      true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+}
+
+// scoredTags are the elements whose text is scored
+var scoredTags = map[atom.Atom]bool{
+	atom.H2:      true,
+	atom.H3:      true,
+	atom.H4:      true,
+	atom.H5:      true,
+	atom.H6:      true,
+	atom.P:       true,
+	atom.Pre:     true,
+	atom.Section: true,
+	atom.Td:      true,
+}
+
+// blockTags stop a <div> from being turned into a paragraph
+var blockTags = map[atom.Atom]bool{
+	atom.Blockquote: true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Figure:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Img:        true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Ul:         true,
+}
+
+// unlikelyNames are class and id fragments of page parts that are not the article
+var unlikelyNames = []string{
+	"-ad-", "ad-break", "agegate", "banner", "breadcrumbs", "combx", "comment",
+	"community", "consent", "cookie", "cover-wrap", "disqus", "extra", "footer",
+	"gdpr", "header", "legends", "menu", "newsletter", "pager", "pagination",
+	"popup", "related", "remark", "replies", "rss", "shoutbox", "sidebar",
+	"skyscraper", "social", "sponsor", "subscribe", "supplemental",
+}
+
+// maybeNames rescue an element with an unlikely name
+var maybeNames = []string{"and", "article", "body", "column", "content", "main", "shadow"}
+
+// positiveNames and negativeNames are class and id fragments that raise or lower a score
+var (
+	positiveNames = []string{
+		"article", "blog", "body", "content", "entry", "hentry", "h-entry",
+		"main", "page", "post", "story", "text",
+	}
+	negativeNames = []string{
+		"-ad-", "banner", "combx", "comment", "com-", "contact", "footer",
+		"gdpr", "hidden", "masthead", "media", "meta", "outbrain", "promo",
+		"related", "scroll", "share", "shoutbox", "sidebar", "skyscraper",
+		"sponsor", "shopping", "tags", "widget",
+	}
+)
+
+// unlikelyRoles are ARIA roles of page parts that are not the article
+var unlikelyRoles = map[string]bool{
+	"alert":         true,
+	"alertdialog":   true,
+	"complementary": true,
+	"dialog":        true,
+	"menu":          true,
+	"menubar":       true,
+	"navigation":    true,
+}
+
+// attempt is the article found by one extraction attempt
+type attempt struct {
+	leadImage string
+	node      *html.Node
+	text      string
+}
+
+// scorer holds the state of one extraction attempt
+type scorer struct {
+	base       *url.URL
+	candidates []*html.Node
+	flags      flags
+	scores     map[*html.Node]float64
+}
+
+// grabArticle finds the article in doc, returning nil when there is no text at all
+func grabArticle(doc *html.Node, f flags, base *url.URL) *attempt {
+	root := findElement(doc, atom.Body)
+	if root == nil {
+		root = doc
+	}
+
+	s := &scorer{base: base, flags: f, scores: make(map[*html.Node]float64)}
+	s.prepare(root)
+	s.score(root)
+
+	top := s.topCandidate()
+	if top == nil {
+		if top = root; top.Type != html.ElementNode {
+			top = findElement(root, atom.Html)
+		}
+		if top == nil {
+			return nil
+		}
+	}
+
+	article := s.collectSiblings(top)
+	s.clean(article)
+
+	a := &attempt{node: article, text: articleText(article)}
+	walk(article, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Img && len(a.leadImage) == 0 {
+			if src := getAttr(n, "src"); len(src) > 0 && !strings.HasPrefix(src, "data:") {
+				a.leadImage = src
+			}
+		}
+		return len(a.leadImage) == 0
+	})
+	return a
+}
+
+// prepare removes everything that is never part of an article, and turns
+// <div> elements used as paragraphs into <p>
+func (s *scorer) prepare(root *html.Node) {
+	var removed []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n == root {
+			return true
+		}
+		switch {
+		case n.Type == html.CommentNode,
+			n.Type == html.ElementNode && (removedTags[n.DataAtom] || isHidden(n)),
+			n.Type == html.ElementNode && s.flags&flagStripUnlikely != 0 && isUnlikely(n):
+			removed = append(removed, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range removed {
+		n.Parent.RemoveChild(n)
+	}
+
+	walk(root, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Div {
+			divToParagraphs(n)
+		}
+		return true
+	})
+}
+
+// score scores every paragraph-like element and passes the score on to its ancestors
+func (s *scorer) score(root *html.Node) {
+	var elements []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && scoredTags[n.DataAtom] {
+			elements = append(elements, n)
+		}
+		return true
+	})
+
+	for _, n := range elements {
+		text := innerText(n)
+		if len(text) < minScoredTextLength {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text)/100), 3)
+
+		level := 0
+		for ancestor := n.Parent; ancestor != nil && ancestor.Type == html.ElementNode && level < maxAncestorLevels; ancestor = ancestor.Parent {
+			if _, ok := s.scores[ancestor]; !ok {
+				s.scores[ancestor] = s.initialScore(ancestor)
+				s.candidates = append(s.candidates, ancestor)
+			}
+			divider := 1.0
+			if level == 1 {
+				divider = 2
+			} else if level > 1 {
+				divider = float64(level * 3)
+			}
+			s.scores[ancestor] += score / divider
+			level++
+		}
+	}
+
+	for _, n := range s.candidates {
+		s.scores[n] *= 1 - linkDensity(n)
+	}
+}
+
+// initialScore is the score of an element before the text inside it is counted
+func (s *scorer) initialScore(n *html.Node) float64 {
+	score := s.classWeight(n)
+	switch n.DataAtom {
+	case atom.Div:
+		score += 5
+	case atom.Blockquote, atom.Pre, atom.Td:
+		score += 3
+	case atom.Address, atom.Dd, atom.Dl, atom.Dt, atom.Form, atom.Li, atom.Ol, atom.Ul:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score
+}
+
+// classWeight rates the class and id of an element, when classes are weighted
+func (s *scorer) classWeight(n *html.Node) float64 {
+	if s.flags&flagWeightClasses == 0 {
+		return 0
+	}
+	var weight float64
+	for _, name := range []string{getAttr(n, "class"), getAttr(n, "id")} {
+		if len(name) == 0 {
+			continue
+		}
+		name = strings.ToLower(name)
+		if containsAny(name, negativeNames) {
+			weight -= classWeight
+		}
+		if containsAny(name, positiveNames) {
+			weight += classWeight
+		}
+	}
+	return weight
+}
+
+// topCandidate returns the candidate with the best score, or nil when nothing was scored
+func (s *scorer) topCandidate() *html.Node {
+	var top *html.Node
+	for _, n := range s.candidates {
+		if top == nil || s.scores[n] > s.scores[top] {
+			top = n
+		}
+	}
+	return top
+}
+
+// collectSiblings moves top into a new <div>, along with its siblings that
+// look like more of the article
+func (s *scorer) collectSiblings(top *html.Node) *html.Node {
+	article := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	if top.Parent == nil || top.DataAtom == atom.Body || top.DataAtom == atom.Html {
+		moveChildren(article, top)
+		return article
+	}
+
+	threshold := max(minSiblingScore, s.scores[top]*siblingScoreFraction)
+	topClass := getAttr(top, "class")
+
+	var keep []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == top {
+			keep = append(keep, sibling)
+			continue
+		}
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		score, scored := s.scores[sibling]
+		if len(topClass) > 0 && getAttr(sibling, "class") == topClass {
+			score += s.scores[top] * siblingScoreFraction
+		}
+		if (scored && score >= threshold) || isParagraphSibling(sibling) {
+			keep = append(keep, sibling)
+		}
+	}
+	for _, n := range keep {
+		n.Parent.RemoveChild(n)
+		article.AppendChild(n)
+	}
+	return article
+}
+
+// isParagraphSibling reports whether a sibling of the top candidate is a
+// paragraph worth keeping even though it was not scored high enough
+func isParagraphSibling(n *html.Node) bool {
+	if n.DataAtom != atom.P {
+		return false
+	}
+	text := innerText(n)
+	density := linkDensity(n)
+	switch {
+	case len(text) > 80:
+		return density < 0.25
+	case len(text) > 0:
+		return density == 0 && (strings.HasSuffix(text, ".") || strings.Contains(text, ". "))
+	}
+	return false
+}
+
+// divToParagraphs turns a <div> without block children into a <p>, or wraps
+// the runs of inline content between its block children in paragraphs
+func divToParagraphs(div *html.Node) {
+	hasBlock := false
+	for c := div.FirstChild; c != nil && !hasBlock; c = c.NextSibling {
+		hasBlock = hasBlockElement(c)
+	}
+	if !hasBlock {
+		div.Data, div.DataAtom = "p", atom.P
+		return
+	}
+
+	var paragraph *html.Node
+	for c := div.FirstChild; c != nil; {
+		next := c.NextSibling
+		if hasBlockElement(c) {
+			paragraph = nil
+		} else if paragraph != nil || c.Type != html.TextNode || len(strings.TrimSpace(c.Data)) > 0 {
+			if paragraph == nil {
+				paragraph = &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P}
+				div.InsertBefore(paragraph, c)
+			}
+			div.RemoveChild(c)
+			paragraph.AppendChild(c)
+		}
+		c = next
+	}
+}
+
+// hasBlockElement reports whether n is or contains a block element
+func hasBlockElement(n *html.Node) bool {
+	found := false
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && blockTags[c.DataAtom] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// isUnlikely reports whether the class, id or role of n marks it as not being the article
+func isUnlikely(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.A, atom.Article, atom.Body, atom.Main:
+		return false
+	}
+	if unlikelyRoles[strings.ToLower(getAttr(n, "role"))] {
+		return true
+	}
+	name := strings.ToLower(getAttr(n, "class") + " " + getAttr(n, "id"))
+	return containsAny(name, unlikelyNames) && !containsAny(name, maybeNames)
+}
+
+// isHidden reports whether n is hidden from readers
+func isHidden(n *html.Node) bool {
+	if hasAttr(n, "hidden") || getAttr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(getAttr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// linkDensity returns the share of the text of n that is inside links
+func linkDensity(n *html.Node) float64 {
+	length := len(innerText(n))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linkLength += len(innerText(c))
+			return false
+		}
+		return true
+	})
+	return float64(linkLength) / float64(length)
+}
+
+// containsAny reports whether s contains any of the fragments
+func containsAny(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package metaextractor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Robots defaults
+const (
+	DefaultRobotsCacheTTL = 24 * time.Hour
+	RobotsRetryTTL        = time.Minute // How long an unavailable robots.txt disallows everything before it is downloaded again
+	maxRobotsSize         = 500 << 10   // RFC 9309 requires parsing at least 500 KiB
+	robotsPath            = "/robots.txt"
+)
+
+// ErrDisallowedByRobots is returned when robots.txt does not allow fetching a URL
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// RobotsError is returned by the Fetcher when a URL is disallowed by robots.txt
+//
+// It matches ErrDisallowedByRobots when used with errors.Is
+type RobotsError struct {
+	URL       string
+	UserAgent string
+}
+
+// Error will return the error message
+func (e *RobotsError) Error() string {
+	return fmt.Sprintf("%s: %s (user-agent %q)", ErrDisallowedByRobots, e.URL, e.UserAgent)
+}
+
+// Unwrap will return ErrDisallowedByRobots
+func (e *RobotsError) Unwrap() error {
+	return ErrDisallowedByRobots
+}
+
+// Robots is a parsed robots.txt file
+type Robots struct {
+	Sitemaps []string
+	groups   []*robotsGroup
+}
+
+// robotsGroup is a set of rules that applies to one or more user-agents
+type robotsGroup struct {
+	agents     []string
+	crawlDelay time.Duration
+	rules      []robotsRule
+}
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsAllowAll and robotsDisallowAll are used when robots.txt could not be parsed
+var (
+	robotsAllowAll    = &Robots{}
+	robotsDisallowAll = &Robots{groups: []*robotsGroup{{
+		agents: []string{"*"},
+		rules:  []robotsRule{{pattern: "/"}},
+	}}}
+)
+
+// ParseRobots will parse a robots.txt file
+//
+// Lines that cannot be understood are ignored, like every major crawler does
+func ParseRobots(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsSize))
+	scanner.Buffer(make([]byte, 0, 4096), maxRobotsSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the same group
+			if !inAgents {
+				group = &robotsGroup{}
+				robots.groups = append(robots.groups, group)
+				inAgents = true
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty disallow allows everything, which is the same as having no rule
+			if group != nil && len(value) > 0 {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 && group != nil {
+				group.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			robots.Sitemaps = append(robots.Sitemaps, value)
+		}
+	}
+
+	return robots, scanner.Err()
+}
+
+// Allowed will return true if userAgent may fetch path (which may include a query string)
+//
+// The most specific (longest) matching rule wins, with Allow winning ties
+func (r *Robots) Allowed(userAgent, path string) bool {
+	if len(path) == 0 || path[0] != '/' {
+		path = "/" + path
+	}
+	if path == robotsPath {
+		return true
+	}
+
+	allowed, length := true, -1
+	for _, group := range r.matchGroups(userAgent) {
+		for _, rule := range group.rules {
+			if !robotsMatch(rule.pattern, path) {
+				continue
+			}
+			if len(rule.pattern) > length || (len(rule.pattern) == length && rule.allow) {
+				allowed, length = rule.allow, len(rule.pattern)
+			}
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay will return the crawl delay requested for userAgent (zero if none)
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, group := range r.matchGroups(userAgent) {
+		if group.crawlDelay > delay {
+			delay = group.crawlDelay
+		}
+	}
+	return delay
+}
+
+// matchGroups returns the groups that apply to userAgent
+//
+// As required by RFC 9309, a group applies when one of its user-agents is the
+// crawler's product token, ignoring case (so "googlebot" does not apply to
+// "googlebot-news"), falling back to the "*" groups
+func (r *Robots) matchGroups(userAgent string) []*robotsGroup {
+	token := robotsProductToken(userAgent)
+
+	var matched, wildcard []*robotsGroup
+	for _, group := range r.groups {
+		switch {
+		case slices.Contains(group.agents, token):
+			matched = append(matched, group)
+		case slices.Contains(group.agents, "*"):
+			wildcard = append(wildcard, group)
+		}
+	}
+
+	if len(matched) > 0 {
+		return matched
+	}
+	return wildcard
+}
+
+// robotsProductToken returns the lowercase product token of a user-agent
+// (e.g. "go-meta-extractor" for "go-meta-extractor/1.0 (+https://...)")
+func robotsProductToken(userAgent string) string {
+	token := strings.TrimSpace(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	return strings.ToLower(token)
+}
+
+// robotsMatch returns true if path matches a robots.txt pattern, where "*"
+// matches any sequence of characters and a trailing "$" anchors the end
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	last := len(parts) - 1
+	for i := 1; i <= last; i++ {
+		if i == last && anchored {
+			return strings.HasSuffix(path[pos:], parts[i])
+		}
+		idx := strings.Index(path[pos:], parts[i])
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(parts[i])
+	}
+	return !anchored || pos == len(path)
+}
+
+// robotsCache caches the robots.txt of every host the Fetcher visits
+type robotsCache struct {
+	entries map[string]*robotsEntry
+	mu      sync.Mutex
+	ttl     time.Duration
+}
+
+// robotsEntry is a cached robots.txt, ready is closed once robots is set
+type robotsEntry struct {
+	expires time.Time
+	ready   chan struct{}
+	robots  *Robots
+}
+
+// newRobotsCache will create a new robots cache
+func newRobotsCache(ttl time.Duration) *robotsCache {
+	return &robotsCache{entries: make(map[string]*robotsEntry), ttl: ttl}
+}
+
+// checkRobots returns a *RobotsError if robots.txt disallows fetching u
+//
+// The robots.txt of each host is only downloaded once per cache TTL, even when
+// many goroutines ask for it at the same time. Its Crawl-delay is applied to
+// the host's rate limit.
+func (f *Fetcher) checkRobots(ctx context.Context, u *url.URL) error {
+	robots, err := f.robotsFor(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	if delay := robots.CrawlDelay(f.robotsAgent); delay > 0 {
+		f.limiter.setCrawlDelay(hostKey(u), delay)
+	}
+
+	path := u.EscapedPath()
+	if len(u.RawQuery) > 0 {
+		path += "?" + u.RawQuery
+	}
+	if !robots.Allowed(f.robotsAgent, path) {
+		return &RobotsError{URL: u.String(), UserAgent: f.robotsAgent}
+	}
+	return nil
+}
+
+// robotsFor returns the (possibly cached) robots.txt for the host of u
+func (f *Fetcher) robotsFor(ctx context.Context, u *url.URL) (*Robots, error) {
+	key := u.Scheme + "://" + hostKey(u)
+	c := f.robots
+
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if ok && entry.robots != nil && f.clock.Now().After(entry.expires) {
+			ok = false
+		}
+		if ok {
+			c.mu.Unlock()
+			select {
+			case <-entry.ready:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if entry.robots == nil {
+				// The goroutine downloading it was canceled, try again
+				continue
+			}
+			return entry.robots, nil
+		}
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.entries[key] = entry
+		c.mu.Unlock()
+
+		robots, unavailable, err := f.downloadRobots(ctx, &url.URL{Scheme: u.Scheme, Host: u.Host, Path: robotsPath})
+
+		c.mu.Lock()
+		if err != nil {
+			delete(c.entries, key)
+		} else {
+			ttl := c.ttl
+			if unavailable {
+				ttl = min(ttl, RobotsRetryTTL)
+			}
+			entry.robots = robots
+			entry.expires = f.clock.Now().Add(ttl)
+		}
+		c.mu.Unlock()
+		close(entry.ready)
+
+		return robots, err
+	}
+}
+
+// downloadRobots fetches and parses robots.txt following RFC 9309: a missing
+// file (4xx) allows everything, while a server error, rate limiting or an
+// unreachable host disallows everything. Since those are usually temporary,
+// unavailable is true and the result is only cached for RobotsRetryTTL.
+//
+// An error is only returned when ctx is canceled, so nothing gets cached
+func (f *Fetcher) downloadRobots(ctx context.Context, u *url.URL) (robots *Robots, unavailable bool, err error) {
+	if err = f.limiter.wait(ctx, hostKey(u)); err != nil {
+		return nil, false, err
+	}
+
+	page, err := f.get(ctx, u)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusBadRequest &&
+			statusErr.StatusCode < http.StatusInternalServerError &&
+			statusErr.StatusCode != http.StatusTooManyRequests {
+			return robotsAllowAll, false, nil
+		}
+		return robotsDisallowAll, true, nil
+	}
+
+	if robots, err = ParseRobots(bytes.NewReader(page.Body)); err != nil {
+		return robotsAllowAll, false, nil //nolint:nilerr // an unreadable robots.txt is treated as a missing one
+	}
+	return robots, false, nil
+}
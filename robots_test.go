@@ -0,0 +1,247 @@
+package metaextractor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRobots = `# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/public-page
+Disallow: /*.pdf$
+Disallow: /search?*q=
+Crawl-delay: 2
+
+User-agent: BadBot
+User-agent: WorseBot
+Disallow: /
+
+User-agent: go-meta-extractor
+Disallow: /no-extractors
+Allow: /private/
+Crawl-delay: 0.5
+
+User-agent: googlebot
+Disallow: /google-only
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+// TestParseRobots will test parsing robots.txt and matching rules
+func TestParseRobots(t *testing.T) {
+	t.Parallel()
+
+	robots, err := ParseRobots(strings.NewReader(testRobots))
+	require.NoError(t, err)
+	require.NotNil(t, robots)
+	assert.Equal(t, []string{"https://example.com/sitemap.xml"}, robots.Sitemaps)
+
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{"wildcard group allows by default", "SomeBot/1.0", "/articles/1", true},
+		{"wildcard group disallows prefix", "SomeBot/1.0", "/private/page", false},
+		{"longer allow wins", "SomeBot/1.0", "/private/public-page", true},
+		{"end anchor matches", "SomeBot/1.0", "/files/report.pdf", false},
+		{"end anchor does not match longer paths", "SomeBot/1.0", "/files/report.pdf.html", true},
+		{"wildcard in the middle", "SomeBot/1.0", "/search?lang=en&q=go", false},
+		{"wildcard in the middle without match", "SomeBot/1.0", "/search?lang=en", true},
+		{"grouped user-agents", "BadBot", "/anything", false},
+		{"second user-agent of a group", "worsebot/2.0 (compatible)", "/anything", false},
+		{"robots.txt is always allowed", "BadBot", "/robots.txt", true},
+		{"specific group replaces wildcard", "go-meta-extractor/1.0 (+https://example.com)", "/private/page", true},
+		{"specific group rules", "go-meta-extractor/1.0", "/no-extractors", false},
+		{"specific group ignores wildcard rules", "go-meta-extractor/1.0", "/files/report.pdf", true},
+		{"group does not match a longer token", "Googlebot-News", "/google-only", true},
+		{"group matches the token ignoring case", "GoogleBot/2.1", "/google-only", false},
+		{"path without leading slash", "BadBot", "anything", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.allowed, robots.Allowed(test.userAgent, test.path))
+		})
+	}
+
+	assert.Equal(t, 2*time.Second, robots.CrawlDelay("SomeBot"))
+	assert.Equal(t, 500*time.Millisecond, robots.CrawlDelay("go-meta-extractor"))
+	assert.Zero(t, robots.CrawlDelay("BadBot"))
+}
+
+// TestRobotsShorterAgent tests that a group for a shorter user-agent does not apply to longer product tokens
+func TestRobotsShorterAgent(t *testing.T) {
+	t.Parallel()
+
+	robots, err := ParseRobots(strings.NewReader("User-agent: go\nAllow: /\n\nUser-agent: *\nDisallow: /\n"))
+	require.NoError(t, err)
+	assert.False(t, robots.Allowed(DefaultUserAgent, "/page"))
+	assert.True(t, robots.Allowed("Go/1.0", "/page"))
+}
+
+// TestParseRobotsEdgeCases will test unusual robots.txt files
+func TestParseRobotsEdgeCases(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		robots  string
+		path    string
+		allowed bool
+	}{
+		{"empty file", "", "/page", true},
+		{"empty disallow allows everything", "User-agent: *\nDisallow:\n", "/page", true},
+		{"rules without a user-agent are ignored", "Disallow: /\n", "/page", true},
+		{"garbage lines are ignored", "this is not robots\nUser-agent: *\nnonsense\nDisallow: /page\n", "/page", false},
+		{"keys are case insensitive", "USER-AGENT: *\nDISALLOW: /page\n", "/page", false},
+		{"comments are stripped", "User-agent: * # everyone\nDisallow: /page # no\n", "/page-two", false},
+		{"allow wins a tie", "User-agent: *\nDisallow: /page\nAllow: /page\n", "/page", true},
+		{"windows line endings", "User-agent: *\r\nDisallow: /page\r\n", "/page", false},
+		{"anchored root only", "User-agent: *\nDisallow: /$\n", "/page", true},
+		{"anchored root matches root", "User-agent: *\nDisallow: /$\n", "/", false},
+		{"trailing wildcard", "User-agent: *\nDisallow: /page*\n", "/pages", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			robots, err := ParseRobots(strings.NewReader(test.robots))
+			require.NoError(t, err)
+			assert.Equal(t, test.allowed, robots.Allowed("AnyBot", test.path))
+		})
+	}
+}
+
+// newRobotsServer returns a server with the given robots.txt response that counts requests to it
+func newRobotsServer(t *testing.T, status int, robots string, robotsRequests, pageRequests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == robotsPath {
+			atomic.AddInt32(robotsRequests, 1)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(robots))
+			return
+		}
+		atomic.AddInt32(pageRequests, 1)
+		_, _ = w.Write([]byte(`<title>` + testTitle + `</title>`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestFetcherRobots will test robots.txt compliance in the fetcher
+func TestFetcherRobots(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disallowed urls are not requested", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /private\n", &robotsRequests, &pageRequests)
+		f := NewFetcher(&FetcherOptions{Clock: newFakeClock(), RespectRobots: true})
+
+		_, err := f.Fetch(context.Background(), server.URL+"/private/page")
+		var robotsErr *RobotsError
+		require.ErrorAs(t, err, &robotsErr)
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Equal(t, server.URL+"/private/page", robotsErr.URL)
+
+		tags, err := f.Extract(context.Background(), server.URL+"/public")
+		require.NoError(t, err)
+		assert.Equal(t, testTitle, tags.Title)
+
+		// robots.txt is cached
+		assert.Equal(t, int32(1), atomic.LoadInt32(&robotsRequests))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&pageRequests))
+	})
+
+	t.Run("robots agent is matched", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusOK, "User-agent: unfurler\nDisallow: /\n", &robotsRequests, &pageRequests)
+
+		_, err := NewFetcher(&FetcherOptions{Clock: newFakeClock(), RespectRobots: true}).Fetch(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+
+		_, err = NewFetcher(&FetcherOptions{Clock: newFakeClock(), RespectRobots: true, RobotsAgent: "Unfurler"}).Fetch(context.Background(), server.URL+"/page")
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+	})
+
+	t.Run("missing robots.txt allows everything", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusNotFound, "", &robotsRequests, &pageRequests)
+
+		_, err := NewFetcher(&FetcherOptions{Clock: newFakeClock(), RespectRobots: true}).Fetch(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+	})
+
+	t.Run("unavailable robots.txt disallows everything", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusServiceUnavailable, "", &robotsRequests, &pageRequests)
+
+		_, err := NewFetcher(&FetcherOptions{Clock: newFakeClock(), RespectRobots: true}).Fetch(context.Background(), server.URL+"/page")
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Zero(t, atomic.LoadInt32(&pageRequests))
+	})
+
+	t.Run("unavailable robots.txt is downloaded again soon", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusServiceUnavailable, "", &robotsRequests, &pageRequests)
+		clock := newFakeClock()
+		f := NewFetcher(&FetcherOptions{Clock: clock, RespectRobots: true})
+
+		_, err := f.Fetch(context.Background(), server.URL+"/page")
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		_, err = f.Fetch(context.Background(), server.URL+"/page")
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&robotsRequests))
+
+		require.NoError(t, clock.Sleep(context.Background(), RobotsRetryTTL+time.Second))
+		_, err = f.Fetch(context.Background(), server.URL+"/page")
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&robotsRequests))
+	})
+
+	t.Run("robots.txt is ignored by default", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /\n", &robotsRequests, &pageRequests)
+
+		_, err := NewFetcher(&FetcherOptions{Clock: newFakeClock()}).Fetch(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+		assert.Zero(t, atomic.LoadInt32(&robotsRequests))
+	})
+
+	t.Run("crawl delay is applied", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 5\n", &robotsRequests, &pageRequests)
+		clock := newFakeClock()
+		f := NewFetcher(&FetcherOptions{Clock: clock, RespectRobots: true})
+
+		for i := 0; i < 3; i++ {
+			_, err := f.Fetch(context.Background(), server.URL+"/page")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []time.Duration{5 * time.Second, 5 * time.Second}, clock.Sleeps())
+	})
+
+	t.Run("robots.txt is downloaded again after the ttl", func(t *testing.T) {
+		var robotsRequests, pageRequests int32
+		server := newRobotsServer(t, http.StatusOK, "", &robotsRequests, &pageRequests)
+		clock := newFakeClock()
+		f := NewFetcher(&FetcherOptions{Clock: clock, RespectRobots: true, RobotsCacheTTL: time.Hour})
+
+		_, err := f.Fetch(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+		require.NoError(t, clock.Sleep(context.Background(), 2*time.Hour))
+		_, err = f.Fetch(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&robotsRequests))
+	})
+}
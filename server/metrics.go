@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metrics holds the counters exposed on /metrics
+type metrics struct {
+	durations map[string]*duration // Keyed by path
+	mu        sync.Mutex
+	rejected  int64
+	requests  map[requestKey]int64
+}
+
+// requestKey identifies a request counter
+type requestKey struct {
+	code int
+	path string
+}
+
+// duration is a summary of request durations
+type duration struct {
+	count int64
+	sum   time.Duration
+}
+
+// newMetrics will create empty metrics
+func newMetrics() *metrics {
+	return &metrics{
+		durations: make(map[string]*duration),
+		requests:  make(map[requestKey]int64),
+	}
+}
+
+// observe records a finished request, unknown paths are grouped together so
+// clients cannot create an unbounded number of series
+func (m *metrics) observe(path string, code int, elapsed time.Duration) {
+	switch path {
+	case PathExtract, PathHealth, PathMetrics:
+	default:
+		path = "other"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{code: code, path: path}]++
+	d, ok := m.durations[path]
+	if !ok {
+		d = &duration{}
+		m.durations[path] = d
+	}
+	d.count++
+	d.sum += elapsed
+}
+
+// reject records a request rejected because every concurrency slot was taken
+func (m *metrics) reject() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejected++
+}
+
+// write writes the metrics in the Prometheus text exposition format
+func (m *metrics) write(w io.Writer, inFlight, capacity int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP metaextract_http_requests_total Number of HTTP requests by path and status code.\n")
+	b.WriteString("# TYPE metaextract_http_requests_total counter\n")
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(&b, "metaextract_http_requests_total{path=%q,code=\"%d\"} %d\n", key.path, key.code, m.requests[key])
+	}
+
+	b.WriteString("# HELP metaextract_http_request_duration_seconds Time spent serving HTTP requests by path.\n")
+	b.WriteString("# TYPE metaextract_http_request_duration_seconds summary\n")
+	paths := make([]string, 0, len(m.durations))
+	for path := range m.durations {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		d := m.durations[path]
+		_, _ = fmt.Fprintf(&b, "metaextract_http_request_duration_seconds_sum{path=%q} %g\n", path, d.sum.Seconds())
+		_, _ = fmt.Fprintf(&b, "metaextract_http_request_duration_seconds_count{path=%q} %d\n", path, d.count)
+	}
+
+	b.WriteString("# HELP metaextract_extractions_in_flight Number of extractions currently running.\n")
+	b.WriteString("# TYPE metaextract_extractions_in_flight gauge\n")
+	_, _ = fmt.Fprintf(&b, "metaextract_extractions_in_flight %d\n", inFlight)
+
+	b.WriteString("# HELP metaextract_extractions_max Maximum number of extractions allowed to run at once.\n")
+	b.WriteString("# TYPE metaextract_extractions_max gauge\n")
+	_, _ = fmt.Fprintf(&b, "metaextract_extractions_max %d\n", capacity)
+
+	b.WriteString("# HELP metaextract_extractions_rejected_total Number of extractions rejected with " +
+		http.StatusText(http.StatusServiceUnavailable) + " because the server was busy.\n")
+	b.WriteString("# TYPE metaextract_extractions_rejected_total counter\n")
+	_, _ = fmt.Fprintf(&b, "metaextract_extractions_rejected_total %d\n", m.rejected)
+
+	_, _ = io.WriteString(w, b.String())
+}
@@ -0,0 +1,316 @@
+// Package server exposes the meta extractor over HTTP, so services written in
+// any language can unfurl links
+//
+// Endpoints:
+//   - GET  /extract?url=<url>  fetches the page and returns its metadata as JSON
+//   - POST /extract            extracts the raw HTML sent as the request body
+//   - GET  /healthz            returns "ok" while the server is running
+//   - GET  /metrics            returns request metrics in the Prometheus text format
+//
+// Both extract endpoints accept the boolean query parameters "alternates",
+// "app_links", "authors", "clean_title", "dates", "detect_language",
+// "fallbacks", "http_equiv", "json_ld", "keywords", "links", "normalize",
+// "platforms", "product", "provenance", "scan_body", "strip_tags" and
+// "warnings" and the number of bytes "scan_limit", matching the fields of
+// metaextractor.ExtractOptions. POST /extract also accepts the address of the
+// document in "url", which GET /extract takes from the fetched page.
+//
+// GET /extract refuses to fetch loopback, private, link-local and other
+// non-public addresses (including redirects to them) unless
+// Options.AllowPrivateAddresses is set, so callers cannot use the server to
+// reach internal services.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+)
+
+// Server defaults
+const (
+	DefaultBodyLimit   = 2 << 20
+	DefaultConcurrency = 32
+	DefaultTimeout     = 15 * time.Second
+)
+
+// errInvalidScanLimit is returned for a scan_limit that is not a non-negative number of bytes
+var errInvalidScanLimit = errors.New("invalid scan_limit query parameter")
+
+// Endpoint paths
+const (
+	PathExtract = "/extract"
+	PathHealth  = "/healthz"
+	PathMetrics = "/metrics"
+)
+
+// Options are the options used when creating a new Server
+type Options struct {
+	AllowPrivateAddresses bool                          // Let the default Fetcher connect to non-public addresses
+	BodyLimit             int64                         // Maximum size in bytes of a POST /extract body
+	Concurrency           int                           // Maximum number of extractions running at once, others get a 503
+	Fetcher               *metaextractor.Fetcher        // Fetcher used by GET /extract (defaults to one made with FetcherOptions)
+	FetcherOptions        *metaextractor.FetcherOptions // Options of the default Fetcher, its Client defaults to one limited to Timeout (see AllowPrivateAddresses)
+	Timeout               time.Duration                 // Time allowed for a single extraction, including the fetch
+}
+
+// DefaultOptions will return the default options for a Server
+func DefaultOptions() *Options {
+	return &Options{
+		BodyLimit:   DefaultBodyLimit,
+		Concurrency: DefaultConcurrency,
+		Timeout:     DefaultTimeout,
+	}
+}
+
+// Server is an http.Handler serving the extract, health and metrics endpoints
+type Server struct {
+	bodyLimit int64
+	fetcher   *metaextractor.Fetcher
+	metrics   *metrics
+	mux       *http.ServeMux
+	slots     chan struct{}
+	timeout   time.Duration
+}
+
+// New will create a new Server, any missing options are replaced with their defaults
+func New(options *Options) *Server {
+	defaults := DefaultOptions()
+	if options == nil {
+		options = defaults
+	}
+
+	s := &Server{
+		bodyLimit: options.BodyLimit,
+		fetcher:   options.Fetcher,
+		metrics:   newMetrics(),
+		mux:       http.NewServeMux(),
+		timeout:   options.Timeout,
+	}
+	if s.bodyLimit <= 0 {
+		s.bodyLimit = defaults.BodyLimit
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaults.Timeout
+	}
+	if s.fetcher == nil {
+		var fetcherOptions metaextractor.FetcherOptions
+		if options.FetcherOptions != nil {
+			fetcherOptions = *options.FetcherOptions
+		}
+		if fetcherOptions.Client == nil {
+			fetcherOptions.Client = fetchClient(options.AllowPrivateAddresses, s.timeout)
+		}
+		s.fetcher = metaextractor.NewFetcher(&fetcherOptions)
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaults.Concurrency
+	}
+	s.slots = make(chan struct{}, concurrency)
+
+	s.mux.HandleFunc("GET "+PathExtract, s.handleExtractURL)
+	s.mux.HandleFunc("POST "+PathExtract, s.handleExtractBody)
+	s.mux.HandleFunc("GET "+PathHealth, s.handleHealth)
+	s.mux.HandleFunc("GET "+PathMetrics, s.handleMetrics)
+	return s
+}
+
+// fetchClient returns the HTTP client of the default Fetcher, which only
+// connects to public addresses unless allowPrivate is set
+func fetchClient(allowPrivate bool, timeout time.Duration) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+	return metaextractor.NewPublicClient(timeout)
+}
+
+// ServeHTTP will route the request to its endpoint and record its metrics
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	s.mux.ServeHTTP(recorder, r)
+	s.metrics.observe(r.URL.Path, recorder.status, time.Since(start))
+}
+
+// HTTPServer will return an *http.Server for s listening on addr, with
+// timeouts suitable for exposing it to other services
+func (s *Server) HTTPServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		IdleTimeout:       2 * time.Minute,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       s.timeout,
+		WriteTimeout:      s.timeout + 5*time.Second,
+	}
+}
+
+// handleExtractURL fetches the page in the "url" query parameter and extracts it
+func (s *Server) handleExtractURL(w http.ResponseWriter, r *http.Request) {
+	rawURL := r.URL.Query().Get("url")
+	if len(rawURL) == 0 {
+		writeError(w, http.StatusBadRequest, "missing url query parameter")
+		return
+	}
+
+	options, err := extractOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.withSlot(w, r, func(ctx context.Context) {
+		result, err := s.fetcher.ExtractWithOptions(ctx, rawURL, options)
+		if err != nil {
+			writeError(w, errorStatus(ctx, err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+// handleExtractBody extracts the raw HTML sent as the request body
+func (s *Server) handleExtractBody(w http.ResponseWriter, r *http.Request) {
+	options, err := extractOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	options.URL = r.URL.Query().Get("url")
+
+	s.withSlot(w, r, func(_ context.Context) {
+		body := http.MaxBytesReader(w, r.Body, s.bodyLimit)
+		result := metaextractor.ExtractWithOptions(body, options)
+
+		// The extractor stops reading shortly after <body>, so an oversized
+		// document is only rejected if the limit was hit before the metadata ended
+		var maxBytesErr *http.MaxBytesError
+		if _, err := body.Read(make([]byte, 1)); errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body is larger than "+strconv.FormatInt(s.bodyLimit, 10)+" bytes")
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+// handleHealth reports that the server is up
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// handleMetrics writes the metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w, len(s.slots), cap(s.slots))
+}
+
+// withSlot runs fn with a timeout once a concurrency slot is free, responding
+// with 503 straight away when every slot is taken
+func (s *Server) withSlot(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context)) {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.metrics.reject()
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "too many concurrent extractions")
+		return
+	}
+	defer func() { <-s.slots }()
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	fn(ctx)
+}
+
+// extractOptions reads the extract options from the query parameters, the
+// "url" parameter is left to the handlers as it differs between them
+func extractOptions(r *http.Request) (metaextractor.ExtractOptions, error) {
+	query := r.URL.Query()
+	scanLimit := 0
+	if value := query.Get("scan_limit"); len(value) > 0 {
+		var err error
+		if scanLimit, err = strconv.Atoi(value); err != nil || scanLimit < 0 {
+			return metaextractor.ExtractOptions{}, errInvalidScanLimit
+		}
+	}
+	return metaextractor.ExtractOptions{
+		Alternates:     queryBool(query.Get("alternates")),
+		AppLinks:       queryBool(query.Get("app_links")),
+		Authors:        queryBool(query.Get("authors")),
+		CleanTitle:     queryBool(query.Get("clean_title")),
+		Dates:          queryBool(query.Get("dates")),
+		DetectLanguage: queryBool(query.Get("detect_language")),
+		Fallbacks:      queryBool(query.Get("fallbacks")),
+		HTTPEquiv:      queryBool(query.Get("http_equiv")),
+		JSONLD:         queryBool(query.Get("json_ld")),
+		Keywords:       queryBool(query.Get("keywords")),
+		Links:          queryBool(query.Get("links")),
+		Normalize:      queryBool(query.Get("normalize")),
+		Platforms:      queryBool(query.Get("platforms")),
+		Product:        queryBool(query.Get("product")),
+		Provenance:     queryBool(query.Get("provenance")),
+		ScanBody:       queryBool(query.Get("scan_body")),
+		ScanLimit:      scanLimit,
+		StripTags:      queryBool(query.Get("strip_tags")),
+		Warnings:       queryBool(query.Get("warnings")),
+	}, nil
+}
+
+// queryBool parses a boolean query parameter, where an empty value is false
+func queryBool(value string) bool {
+	b, _ := strconv.ParseBool(value)
+	return b
+}
+
+// errorStatus maps a fetch error to the status code returned to the client
+func errorStatus(ctx context.Context, err error) int {
+	switch {
+	case errors.Is(err, metaextractor.ErrInvalidURL):
+		return http.StatusBadRequest
+	case errors.Is(err, metaextractor.ErrDisallowedByRobots), errors.Is(err, metaextractor.ErrNonPublicAddress):
+		return http.StatusForbidden
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// errorResponse is the JSON body of an error
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+// WriteHeader records the status code before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metaextractor "github.com/mrz1836/go-meta-extractor"
+)
+
+const testPage = `<html><head><title>Test Title</title><meta property="og:title" content="OG Title"></head><body></body></html>`
+
+// newUpstream creates a server that plays the role of the pages being unfurled
+func newUpstream(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	if handler == nil {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/missing":
+				http.NotFound(w, r)
+			case "/robots.txt":
+				_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			default:
+				_, _ = w.Write([]byte(testPage))
+			}
+		}
+	}
+	upstream := httptest.NewServer(handler)
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// do sends a request to the server and returns the status code and body
+func do(t *testing.T, s *Server, method, target, body string) (int, string) {
+	t.Helper()
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = strings.NewReader(body)
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(method, target, reader))
+	return recorder.Code, recorder.Body.String()
+}
+
+// extractPath builds the GET /extract path for a URL
+func extractPath(rawURL, extra string) string {
+	return PathExtract + "?url=" + url.QueryEscape(rawURL) + extra
+}
+
+// TestNew tests creating a server with the default options
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	s := New(nil)
+	require.NotNil(t, s)
+	assert.Equal(t, int64(DefaultBodyLimit), s.bodyLimit)
+	assert.Equal(t, DefaultConcurrency, cap(s.slots))
+	assert.Equal(t, DefaultTimeout, s.timeout)
+	assert.NotNil(t, s.fetcher)
+
+	// The default fetcher's client is bound by the server timeout
+	assert.Equal(t, 3*time.Second, fetchClient(false, 3*time.Second).Timeout)
+	assert.Equal(t, 3*time.Second, fetchClient(true, 3*time.Second).Timeout)
+
+	httpServer := s.HTTPServer(":8080")
+	assert.Equal(t, ":8080", httpServer.Addr)
+	assert.Equal(t, s, httpServer.Handler)
+	assert.Positive(t, httpServer.ReadHeaderTimeout)
+}
+
+// TestExtractURL tests GET /extract
+func TestExtractURL(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil)
+	s := New(&Options{Fetcher: metaextractor.NewFetcher(&metaextractor.FetcherOptions{MaxRetries: -1, RespectRobots: true})})
+
+	t.Run("success", func(t *testing.T) {
+		code, body := do(t, s, http.MethodGet, extractPath(upstream.URL, "&provenance=true"), "")
+		require.Equal(t, http.StatusOK, code, body)
+
+		var result metaextractor.Result
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, "Test Title", result.Title)
+		assert.Equal(t, "OG Title", result.OGTitle)
+		assert.Equal(t, metaextractor.TagTitle, result.Provenance[metaextractor.FieldTitle].Source)
+	})
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+		message  string
+	}{
+		{"missing url", PathExtract, http.StatusBadRequest, "missing url query parameter"},
+		{"invalid url", extractPath("ftp://example.com", ""), http.StatusBadRequest, "invalid url"},
+		{"upstream error", extractPath(upstream.URL+"/missing", ""), http.StatusBadGateway, "unexpected http status code: 404"},
+		{"disallowed by robots.txt", extractPath(upstream.URL+"/private", ""), http.StatusForbidden, "disallowed by robots.txt"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, body := do(t, s, http.MethodGet, test.target, "")
+			assert.Equal(t, test.expected, code)
+
+			var response errorResponse
+			require.NoError(t, json.Unmarshal([]byte(body), &response))
+			assert.Contains(t, response.Error, test.message)
+		})
+	}
+}
+
+// TestExtractURLPrivateAddresses tests that the default fetcher refuses non-public addresses
+func TestExtractURLPrivateAddresses(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil)
+	redirect := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, upstream.URL, http.StatusFound)
+	})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"loopback", upstream.URL},
+		{"redirect to loopback", redirect.URL},
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data/"},
+		{"private network", "http://10.0.0.1/"},
+	}
+	s := New(nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, body := do(t, s, http.MethodGet, extractPath(test.target, ""), "")
+			assert.Equal(t, http.StatusForbidden, code)
+
+			var response errorResponse
+			require.NoError(t, json.Unmarshal([]byte(body), &response))
+			assert.Contains(t, response.Error, metaextractor.ErrNonPublicAddress.Error())
+		})
+	}
+
+	t.Run("allowed when opted out", func(t *testing.T) {
+		code, body := do(t, New(&Options{AllowPrivateAddresses: true}), http.MethodGet, extractPath(redirect.URL, ""), "")
+		require.Equal(t, http.StatusOK, code, body)
+		assert.Contains(t, body, `"title":"Test Title"`)
+	})
+}
+
+// TestExtractURLTimeout tests that slow pages time out
+func TestExtractURLTimeout(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = w.Write([]byte(testPage))
+	})
+	s := New(&Options{
+		Fetcher: metaextractor.NewFetcher(&metaextractor.FetcherOptions{MaxRetries: -1}),
+		Timeout: 50 * time.Millisecond,
+	})
+
+	code, _ := do(t, s, http.MethodGet, extractPath(upstream.URL, ""), "")
+	assert.Equal(t, http.StatusGatewayTimeout, code)
+}
+
+// TestExtractBody tests POST /extract
+func TestExtractBody(t *testing.T) {
+	t.Parallel()
+
+	s := New(&Options{BodyLimit: 8192})
+
+	t.Run("success", func(t *testing.T) {
+		code, body := do(t, s, http.MethodPost, PathExtract+"?warnings=1", testPage)
+		require.Equal(t, http.StatusOK, code, body)
+
+		var result metaextractor.Result
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, "Test Title", result.Title)
+		assert.Nil(t, result.Provenance)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		page := `<head><link rel="canonical" href="https://example.com/story">
+			<script type="application/ld+json">{"@type":"NewsArticle"}</script></head>
+			<body><p>` + strings.Repeat("x", 200) + `</p><time datetime="2024-06-01">June</time></body>`
+		query := "?dates=1&json_ld=true&links=1&scan_limit=100&url=" + url.QueryEscape("https://example.com/news/2024/05/12/story")
+		code, body := do(t, s, http.MethodPost, PathExtract+query, page)
+		require.Equal(t, http.StatusOK, code, body)
+
+		var result metaextractor.Result
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		assert.Len(t, result.JSONLD, 1)
+		require.Len(t, result.Links, 1)
+		assert.Equal(t, "https://example.com/story", result.Links[0].Href)
+		require.NotNil(t, result.Dates)
+		require.NotNil(t, result.Dates.Published)
+		assert.Equal(t, "2024-05-12", result.Dates.Published.Raw)
+		assert.Len(t, result.Dates.Candidates, 1)
+	})
+
+	t.Run("invalid scan limit", func(t *testing.T) {
+		for _, limit := range []string{"lots", "-1"} {
+			code, body := do(t, s, http.MethodPost, PathExtract+"?scan_limit="+limit, testPage)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Contains(t, body, "invalid scan_limit query parameter")
+		}
+	})
+
+	t.Run("large documents are fine when the head fits", func(t *testing.T) {
+		code, _ := do(t, s, http.MethodPost, PathExtract, testPage+strings.Repeat(" ", 20000))
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("head larger than the limit", func(t *testing.T) {
+		code, body := do(t, s, http.MethodPost, PathExtract, "<head>"+strings.Repeat("<meta>", 2000)+"</head>")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Contains(t, body, "request body is larger than 8192 bytes")
+	})
+}
+
+// TestConcurrencyLimit tests that extractions over the limit are rejected
+func TestConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	upstream := newUpstream(t, func(w http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte(testPage))
+	})
+	s := New(&Options{AllowPrivateAddresses: true, Concurrency: 1})
+
+	done := make(chan int)
+	go func() {
+		code, _ := do(t, s, http.MethodGet, extractPath(upstream.URL, ""), "")
+		done <- code
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, PathExtract, strings.NewReader(testPage)))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	_, metricsBody := do(t, s, http.MethodGet, PathMetrics, "")
+	assert.Contains(t, metricsBody, "metaextract_extractions_in_flight 1\n")
+	assert.Contains(t, metricsBody, "metaextract_extractions_rejected_total 1\n")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+}
+
+// TestHealthAndMetrics tests the health and metrics endpoints
+func TestHealthAndMetrics(t *testing.T) {
+	t.Parallel()
+
+	s := New(nil)
+
+	code, body := do(t, s, http.MethodGet, PathHealth, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	code, _ = do(t, s, http.MethodPost, PathExtract, testPage)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(t, s, http.MethodGet, "/nope", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = do(t, s, http.MethodDelete, PathExtract, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, PathMetrics, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+
+	body = recorder.Body.String()
+	assert.Contains(t, body, "# TYPE metaextract_http_requests_total counter\n")
+	assert.Contains(t, body, `metaextract_http_requests_total{path="/extract",code="200"} 1`+"\n")
+	assert.Contains(t, body, `metaextract_http_requests_total{path="/extract",code="405"} 1`+"\n")
+	assert.Contains(t, body, `metaextract_http_requests_total{path="/healthz",code="200"} 1`+"\n")
+	assert.Contains(t, body, `metaextract_http_requests_total{path="other",code="404"} 1`+"\n")
+	assert.Contains(t, body, `metaextract_http_request_duration_seconds_count{path="/extract"} 2`+"\n")
+	assert.Contains(t, body, "metaextract_extractions_max 32\n")
+}
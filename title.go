@@ -0,0 +1,98 @@
+package metaextractor
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// FieldOriginalTitle is the field name of Result.OriginalTitle, whose
+// provenance is the one the title had before it was cleaned
+const FieldOriginalTitle = "original_title"
+
+// titleSeparators split a page title from the site name (e.g. "Story | Example News")
+var titleSeparators = []string{" | ", " - ", " – ", " — ", " · ", " • ", " :: ", " » ", " / "}
+
+// secondLevelDomains are the labels that come before a country code in hosts
+// like "bbc.co.uk", where the site is named by the label before them
+var secondLevelDomains = map[string]bool{
+	"ac": true, "co": true, "com": true, "edu": true, "gov": true, "net": true, "org": true,
+}
+
+// CleanTitle will remove the branding segment from a page title, for example
+// "Article headline - The Daily Paper" becomes "Article headline"
+//
+// A segment is removed when it is the first or last part of the title (split
+// on |, -, –, —, ·, •, ::, » or /) and matches the site name or the host, ignoring
+// case, punctuation and a leading "The". Only one segment is removed and the
+// title is returned unchanged if nothing would be left.
+func CleanTitle(title, siteName, host string) string {
+	names := siteNames(siteName, host)
+	if len(names) == 0 {
+		return title
+	}
+	isSiteName := func(segment string) bool {
+		// Hosts keep the "The" of their brand (e.g. "The Verge" is theverge.com)
+		return slices.Contains(names, comparableName(segment)) || slices.Contains(names, lettersAndDigits(segment))
+	}
+
+	for _, sep := range titleSeparators {
+		if i := strings.LastIndex(title, sep); i > 0 && isSiteName(title[i+len(sep):]) {
+			if cleaned := strings.TrimSpace(title[:i]); len(cleaned) > 0 {
+				return cleaned
+			}
+		}
+		if i := strings.Index(title, sep); i > 0 && isSiteName(title[:i]) {
+			if cleaned := strings.TrimSpace(title[i+len(sep):]); len(cleaned) > 0 {
+				return cleaned
+			}
+		}
+	}
+	return title
+}
+
+// siteNames returns the comparable names a site may use in its titles
+func siteNames(siteName, host string) []string {
+	var names []string
+	add := func(name string) {
+		if len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	add(comparableName(siteName))
+	host = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "www.")
+	if len(host) > 0 {
+		add(lettersAndDigits(host))
+		labels := strings.Split(host, ".")
+		if len(labels) > 1 {
+			add(lettersAndDigits(strings.Join(labels[:len(labels)-1], ".")))
+			name := labels[len(labels)-2]
+			if len(labels) > 2 && secondLevelDomains[name] {
+				name = labels[len(labels)-3]
+			}
+			add(lettersAndDigits(name))
+		}
+	}
+	return names
+}
+
+// comparableName lowercases s, drops a leading "The" and removes everything but
+// letters and digits, so "The Daily Paper" matches "daily-paper" and "dailypaper"
+func comparableName(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 4 && strings.EqualFold(s[:4], "the ") {
+		s = s[4:]
+	}
+	return lettersAndDigits(s)
+}
+
+// lettersAndDigits lowercases s and removes everything but letters and digits
+func lettersAndDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,102 @@
+package metaextractor
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// titleCase is a single entry of testdata/titles.json
+type titleCase struct {
+	Expected string `json:"expected"`
+	Host     string `json:"host"`
+	SiteName string `json:"site_name"`
+	Title    string `json:"title"`
+}
+
+// TestCleanTitle tests removing the site name from real-world title patterns
+func TestCleanTitle(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/titles.json")
+	require.NoError(t, err)
+
+	var cases []titleCase
+	require.NoError(t, json.Unmarshal(data, &cases))
+	require.NotEmpty(t, cases)
+
+	for _, test := range cases {
+		t.Run(test.Title, func(t *testing.T) {
+			assert.Equal(t, test.Expected, CleanTitle(test.Title, test.SiteName, test.Host))
+		})
+	}
+}
+
+// TestExtractWithOptionsCleanTitle tests cleaning the title during extraction
+func TestExtractWithOptionsCleanTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		mockHTML      string
+		options       ExtractOptions
+		expected      string
+		originalTitle string
+	}{
+		{
+			name:     "disabled by default",
+			mockHTML: `<head><title>Story | Example News</title><meta property="og:site_name" content="Example News"></head>`,
+			expected: "Story | Example News",
+		},
+		{
+			name:          "site name found after the title",
+			mockHTML:      `<head><title>Story | Example News</title><meta property="og:site_name" content="Example News"></head>`,
+			options:       ExtractOptions{CleanTitle: true},
+			expected:      "Story",
+			originalTitle: "Story | Example News",
+		},
+		{
+			name:          "host of the document",
+			mockHTML:      `<head><title>Home | Shop</title></head>`,
+			options:       ExtractOptions{CleanTitle: true, URL: "https://www.shop.com/"},
+			expected:      "Home",
+			originalTitle: "Home | Shop",
+		},
+		{
+			name:     "nothing to remove",
+			mockHTML: `<head><title>Home | Shop</title></head>`,
+			options:  ExtractOptions{CleanTitle: true, URL: "://bad"},
+			expected: "Home | Shop",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractWithOptions(strings.NewReader(test.mockHTML), test.options)
+			require.NotNil(t, result)
+			assert.Equal(t, test.expected, result.Title)
+			assert.Equal(t, test.originalTitle, result.OriginalTitle)
+		})
+	}
+
+	t.Run("provenance of the cleaned title", func(t *testing.T) {
+		page := "<head>\n<title>Story | Example News</title><meta property=\"og:site_name\" content=\"Example News\"></head>"
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{CleanTitle: true, Provenance: true})
+		require.NotNil(t, result)
+		original := Provenance{Line: 2, Offset: strings.Index(page, "Story"), Source: TagTitle}
+		assert.Equal(t, original, result.Provenance[FieldOriginalTitle])
+		original.Cleaned = true
+		assert.Equal(t, original, result.Provenance[FieldTitle])
+	})
+
+	t.Run("provenance of a title left alone", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(`<head><title>Home | Shop</title></head>`), ExtractOptions{CleanTitle: true, Provenance: true})
+		require.NotNil(t, result)
+		assert.False(t, result.Provenance[FieldTitle].Cleaned)
+		assert.NotContains(t, result.Provenance, FieldOriginalTitle)
+	})
+}
@@ -0,0 +1,238 @@
+package metaextractor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnknownSeverity is returned when decoding a severity name that is not known
+var ErrUnknownSeverity = errors.New("unknown severity")
+
+// Severity is how serious a validation Finding is
+type Severity int
+
+// Severity levels, ordered from least to most serious
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// String will return the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalText will encode the severity as its name (used for JSON)
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText will decode the name of a severity, ignoring case (used for JSON and flags)
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, severity := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if strings.EqualFold(string(text), severity.String()) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownSeverity, text)
+}
+
+// Validation rules
+const (
+	RuleDescriptionLength  = "description-length"  // Descriptions should fit in the space platforms display
+	RuleDescriptionMissing = "description-missing" // Pages should have a description
+	RuleImageURL           = "image-url"           // Images must be absolute http(s) URLs, https preferred
+	RuleOGRequired         = "og-required"         // og:title, og:type, og:image and og:url are required
+	RuleOGURL              = "og-url"              // og:url must be an absolute http(s) URL
+	RuleTitleLength        = "title-length"        // Titles should fit in the space platforms display
+	RuleTwitterCard        = "twitter-card"        // twitter:card must be one of the documented card types
+	RuleTwitterPlayer      = "twitter-player"      // Player cards need a player URL, width and height
+)
+
+// Recommended maximum lengths in characters
+const (
+	MaxRecommendedDescriptionLength        = 160
+	MaxRecommendedTitleLength              = 70
+	MaxRecommendedTwitterDescriptionLength = 200
+)
+
+// Twitter card types
+const (
+	TwitterCardApp               = "app"
+	TwitterCardPlayer            = "player"
+	TwitterCardSummary           = "summary"
+	TwitterCardSummaryLargeImage = "summary_large_image"
+)
+
+// Finding is a single result of validating extracted metadata
+type Finding struct {
+	Field    string   `json:"field"` // Field the finding is about (see the Field constants)
+	Message  string   `json:"message"`
+	Rule     string   `json:"rule"` // Rule that produced the finding (see the Rule constants)
+	Severity Severity `json:"severity"`
+}
+
+// Findings is a list of validation findings
+type Findings []Finding
+
+// HasErrors will return true if any finding has SeverityError
+func (f Findings) HasErrors() bool {
+	return f.Count(SeverityError) > 0
+}
+
+// Count will return the number of findings with the given severity
+func (f Findings) Count(severity Severity) int {
+	count := 0
+	for _, finding := range f {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Validate will check extracted metadata against the Open Graph and Twitter card rules
+//
+// Required Open Graph properties must be listed in Result.OGDeclared, so an
+// og:image that fell back to twitter:image is reported as missing. With
+// provenance enabled, the findings also name the tag the value came from
+func Validate(result *Result) Findings {
+	if result == nil {
+		return nil
+	}
+	v := &validator{result: result}
+
+	// Open Graph (https://ogp.me/#metadata)
+	v.requireOG(FieldOGTitle, TagOGTitle, result.OGTitle)
+	v.requireOG(FieldOGType, TagOGType, result.OGType)
+	v.requireOG(FieldOGImage, TagOGImage, result.OGImage)
+	v.requireOG(FieldOGURL, TagOGURL, result.OGURL)
+	if len(result.OGURL) > 0 {
+		if u, err := url.Parse(result.OGURL); err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			v.add(SeverityError, RuleOGURL, FieldOGURL, fmt.Sprintf("og:url %q is not an absolute http(s) URL", result.OGURL))
+		}
+	}
+
+	// Images
+	v.checkImage(FieldOGImage, TagOGImage, result.OGImage)
+	if result.TwitterImage != result.OGImage {
+		v.checkImage(FieldTwitterImage, TagTwitterImage, result.TwitterImage)
+	}
+
+	// Twitter cards (https://developer.x.com/en/docs/x-for-websites/cards/overview/markup)
+	v.checkTwitterCard()
+
+	// Lengths
+	if len(result.Description) == 0 {
+		v.add(SeverityWarning, RuleDescriptionMissing, FieldDescription, "page has no description")
+	}
+	v.checkLength(FieldDescription, result.Description, MaxRecommendedDescriptionLength)
+	v.checkLength(FieldTwitterDescription, result.TwitterDescription, MaxRecommendedTwitterDescriptionLength)
+	v.checkLength(FieldTitle, result.Title, MaxRecommendedTitleLength)
+
+	return v.findings
+}
+
+// validator collects the findings of Validate
+type validator struct {
+	findings Findings
+	result   *Result
+}
+
+// add records a finding
+func (v *validator) add(severity Severity, rule, field, message string) {
+	v.findings = append(v.findings, Finding{Field: field, Message: message, Rule: rule, Severity: severity})
+}
+
+// requireOG records an error if a required Open Graph property is missing,
+// including when the value only exists because of a fallback to another tag
+func (v *validator) requireOG(field, tag, value string) {
+	if len(value) == 0 {
+		v.add(SeverityError, RuleOGRequired, field, tag+" is required")
+		return
+	}
+	if slices.Contains(v.result.OGDeclared, tag) {
+		return
+	}
+	source := "another tag"
+	if p, ok := v.result.Provenance[field]; ok {
+		source = p.Source
+	}
+	v.add(SeverityError, RuleOGRequired, field, fmt.Sprintf("%s is required (value was taken from %s)", tag, source))
+}
+
+// checkImage validates the URL of an image
+func (v *validator) checkImage(field, tag, value string) {
+	if len(value) == 0 {
+		return
+	}
+	u, err := url.Parse(value)
+	switch {
+	case err != nil || !u.IsAbs() || len(u.Host) == 0:
+		v.add(SeverityError, RuleImageURL, field, fmt.Sprintf("%s %q is not an absolute URL", tag, value))
+	case u.Scheme == "http":
+		v.add(SeverityWarning, RuleImageURL, field, fmt.Sprintf("%s %q should use https", tag, value))
+	case u.Scheme != "https":
+		v.add(SeverityError, RuleImageURL, field, fmt.Sprintf("%s %q is not an http(s) URL", tag, value))
+	}
+}
+
+// checkTwitterCard validates the card type and the properties each type needs
+func (v *validator) checkTwitterCard() {
+	card := v.result.TwitterCard
+	switch card {
+	case "":
+		v.add(SeverityInfo, RuleTwitterCard, FieldTwitterCard, "twitter:card is missing, a summary card will be used")
+	case TwitterCardSummary, TwitterCardSummaryLargeImage, TwitterCardApp:
+	case TwitterCardPlayer:
+		if len(v.result.TwitterPlayer) == 0 {
+			v.add(SeverityError, RuleTwitterPlayer, FieldTwitterPlayer, "player cards require twitter:player")
+		}
+		v.checkPlayerSize(FieldTwitterPlayerWidth, TagTwitterPlayerWidth, v.result.TwitterPlayerWidth)
+		v.checkPlayerSize(FieldTwitterPlayerHeight, TagTwitterPlayerHeight, v.result.TwitterPlayerHeight)
+	default:
+		v.add(SeverityError, RuleTwitterCard, FieldTwitterCard, fmt.Sprintf(
+			"twitter:card %q must be one of %s, %s, %s or %s",
+			card, TwitterCardSummary, TwitterCardSummaryLargeImage, TwitterCardApp, TwitterCardPlayer,
+		))
+	}
+}
+
+// checkPlayerSize validates a player width or height
+func (v *validator) checkPlayerSize(field, tag, value string) {
+	if len(value) == 0 {
+		v.add(SeverityError, RuleTwitterPlayer, field, "player cards require "+tag)
+		return
+	}
+	if size, err := strconv.Atoi(value); err != nil || size <= 0 {
+		v.add(SeverityError, RuleTwitterPlayer, field, fmt.Sprintf("%s %q must be a positive number of pixels", tag, value))
+	}
+}
+
+// checkLength records a warning if a value is longer than the recommended length
+func (v *validator) checkLength(field, value string, maxLength int) {
+	rule := RuleDescriptionLength
+	if field == FieldTitle {
+		rule = RuleTitleLength
+	}
+	if length := utf8.RuneCountInString(value); length > maxLength {
+		v.add(SeverityWarning, rule, field, fmt.Sprintf(
+			"%s is %d characters, the recommended maximum is %d", field, length, maxLength,
+		))
+	}
+}
@@ -0,0 +1,220 @@
+package metaextractor
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validPage is a page with complete Open Graph and Twitter card metadata
+const validPage = `<html><head>
+<title>` + testTitle + `</title>
+<meta name="description" content="` + testDescription + `">
+<meta property="og:title" content="` + testTitle + `">
+<meta property="og:type" content="article">
+<meta property="og:url" content="https://example.com/article">
+<meta property="og:image" content="https://example.com/image.png">
+<meta name="twitter:card" content="summary_large_image">
+</head></html>`
+
+// TestValidate will test validating extracted metadata
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid page has no findings", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(validPage), ExtractOptions{Provenance: true})
+		assert.Empty(t, Validate(result))
+	})
+
+	t.Run("valid page without provenance", func(t *testing.T) {
+		result := ExtractWithOptions(strings.NewReader(validPage), ExtractOptions{})
+		assert.Equal(t, []string{TagOGTitle, TagOGType, TagOGURL, TagOGImage}, result.OGDeclared)
+		assert.Empty(t, Validate(result))
+	})
+
+	t.Run("og:image taken from twitter:image without provenance", func(t *testing.T) {
+		page := strings.Replace(validPage, `property="og:image"`, `name="twitter:image"`, 1)
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{})
+		assert.Equal(t, "https://example.com/image.png", result.OGImage)
+		assert.Equal(t, Findings{
+			{Field: FieldOGImage, Message: "og:image is required (value was taken from another tag)", Rule: RuleOGRequired, Severity: SeverityError},
+		}, Validate(result))
+	})
+
+	t.Run("empty og:image does not count as declared", func(t *testing.T) {
+		page := strings.Replace(validPage, "</head>", `<meta property="og:image" content=" "><meta name="twitter:image" content="https://example.com/image.png"></head>`, 1)
+		page = strings.Replace(page, `<meta property="og:image" content="https://example.com/image.png">`, "", 1)
+		result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Normalize: true, Provenance: true})
+		assert.Equal(t, Findings{
+			{Field: FieldOGImage, Message: "og:image is required (value was taken from twitter:image)", Rule: RuleOGRequired, Severity: SeverityError},
+		}, Validate(result))
+	})
+
+	t.Run("nil result", func(t *testing.T) {
+		assert.Nil(t, Validate(nil))
+	})
+
+	tests := []struct {
+		name     string
+		replace  []string // Pairs of old/new strings applied to validPage
+		expected []Finding
+	}{
+		{
+			name:    "missing og:type and og:url",
+			replace: []string{`<meta property="og:type" content="article">`, "", `<meta property="og:url" content="https://example.com/article">`, ""},
+			expected: []Finding{
+				{Field: FieldOGType, Message: "og:type is required", Rule: RuleOGRequired, Severity: SeverityError},
+				{Field: FieldOGURL, Message: "og:url is required", Rule: RuleOGRequired, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "og:image taken from twitter:image",
+			replace: []string{`property="og:image" content="https://example.com/image.png"`, `name="twitter:image" content="https://example.com/image.png"`},
+			expected: []Finding{
+				{Field: FieldOGImage, Message: "og:image is required (value was taken from twitter:image)", Rule: RuleOGRequired, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "relative og:url",
+			replace: []string{"https://example.com/article", "/article"},
+			expected: []Finding{
+				{Field: FieldOGURL, Message: `og:url "/article" is not an absolute http(s) URL`, Rule: RuleOGURL, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "relative image",
+			replace: []string{"https://example.com/image.png", "/image.png"},
+			expected: []Finding{
+				{Field: FieldOGImage, Message: `og:image "/image.png" is not an absolute URL`, Rule: RuleImageURL, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "insecure image",
+			replace: []string{"https://example.com/image.png", "http://example.com/image.png"},
+			expected: []Finding{
+				{Field: FieldOGImage, Message: `og:image "http://example.com/image.png" should use https`, Rule: RuleImageURL, Severity: SeverityWarning},
+			},
+		},
+		{
+			name:    "non http image",
+			replace: []string{"https://example.com/image.png", "ftp://example.com/image.png"},
+			expected: []Finding{
+				{Field: FieldOGImage, Message: `og:image "ftp://example.com/image.png" is not an http(s) URL`, Rule: RuleImageURL, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "unknown twitter card",
+			replace: []string{"summary_large_image", "gallery"},
+			expected: []Finding{
+				{Field: FieldTwitterCard, Message: `twitter:card "gallery" must be one of summary, summary_large_image, app or player`, Rule: RuleTwitterCard, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "missing twitter card",
+			replace: []string{`<meta name="twitter:card" content="summary_large_image">`, ""},
+			expected: []Finding{
+				{Field: FieldTwitterCard, Message: "twitter:card is missing, a summary card will be used", Rule: RuleTwitterCard, Severity: SeverityInfo},
+			},
+		},
+		{
+			name:    "player card without a player",
+			replace: []string{"summary_large_image", "player"},
+			expected: []Finding{
+				{Field: FieldTwitterPlayer, Message: "player cards require twitter:player", Rule: RuleTwitterPlayer, Severity: SeverityError},
+				{Field: FieldTwitterPlayerWidth, Message: "player cards require twitter:player:width", Rule: RuleTwitterPlayer, Severity: SeverityError},
+				{Field: FieldTwitterPlayerHeight, Message: "player cards require twitter:player:height", Rule: RuleTwitterPlayer, Severity: SeverityError},
+			},
+		},
+		{
+			name: "player card with an invalid size",
+			replace: []string{"summary_large_image", `player"><meta name="twitter:player" content="https://example.com/embed">` +
+				`<meta name="twitter:player:width" content="640"><meta name="twitter:player:height" content="tall`},
+			expected: []Finding{
+				{Field: FieldTwitterPlayerHeight, Message: `twitter:player:height "tall" must be a positive number of pixels`, Rule: RuleTwitterPlayer, Severity: SeverityError},
+			},
+		},
+		{
+			name:    "missing description",
+			replace: []string{`<meta name="description" content="` + testDescription + `">`, ""},
+			expected: []Finding{
+				{Field: FieldDescription, Message: "page has no description", Rule: RuleDescriptionMissing, Severity: SeverityWarning},
+			},
+		},
+		{
+			name:    "long description and title",
+			replace: []string{testDescription, strings.Repeat("é", 161), "<title>" + testTitle, "<title>" + strings.Repeat("T", 71)},
+			expected: []Finding{
+				{Field: FieldDescription, Message: "description is 161 characters, the recommended maximum is 160", Rule: RuleDescriptionLength, Severity: SeverityWarning},
+				{Field: FieldTitle, Message: "title is 71 characters, the recommended maximum is 70", Rule: RuleTitleLength, Severity: SeverityWarning},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := strings.NewReplacer(test.replace...).Replace(validPage)
+			result := ExtractWithOptions(strings.NewReader(page), ExtractOptions{Provenance: true})
+			assert.Equal(t, Findings(test.expected), Validate(result))
+		})
+	}
+}
+
+// TestFindings will test the helpers of a list of findings
+func TestFindings(t *testing.T) {
+	t.Parallel()
+
+	findings := Findings{
+		{Severity: SeverityWarning},
+		{Severity: SeverityInfo},
+		{Severity: SeverityWarning},
+	}
+	assert.False(t, findings.HasErrors())
+	assert.Equal(t, 2, findings.Count(SeverityWarning))
+
+	findings = append(findings, Finding{Severity: SeverityError})
+	assert.True(t, findings.HasErrors())
+	assert.False(t, Findings(nil).HasErrors())
+}
+
+// TestSeverity will test the names of the severities
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "info", SeverityInfo.String())
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "error", SeverityError.String())
+	assert.Equal(t, "unknown", Severity(42).String())
+
+	data, err := json.Marshal(Finding{Field: FieldTitle, Rule: RuleTitleLength, Severity: SeverityError})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"field":"title","message":"","rule":"title-length","severity":"error"}`, string(data))
+}
+
+// TestSeverityUnmarshalText will test decoding the names of the severities
+func TestSeverityUnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	findings := Findings{
+		{Field: FieldTwitterCard, Message: "twitter:card is missing", Rule: RuleTwitterCard, Severity: SeverityInfo},
+		{Field: FieldDescription, Message: "page has no description", Rule: RuleDescriptionMissing, Severity: SeverityWarning},
+		{Field: FieldOGURL, Message: "og:url is required", Rule: RuleOGRequired, Severity: SeverityError},
+	}
+	data, err := json.Marshal(findings)
+	require.NoError(t, err)
+
+	var decoded Findings
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, findings, decoded)
+
+	var severity Severity
+	require.NoError(t, severity.UnmarshalText([]byte("Warning")))
+	assert.Equal(t, SeverityWarning, severity)
+
+	err = severity.UnmarshalText([]byte("fatal"))
+	require.ErrorIs(t, err, ErrUnknownSeverity)
+	assert.Contains(t, err.Error(), `"fatal"`)
+	require.ErrorIs(t, json.Unmarshal([]byte(`{"severity":"unknown"}`), &Finding{}), ErrUnknownSeverity)
+}